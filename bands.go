@@ -0,0 +1,63 @@
+package dds
+
+import (
+	"context"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// DecodeBands reads a dds file from r and passes the decoded texture to fn in bands of four rows of pixels, one row of
+// blocks of compressed formats, from top to bottom. Only a single band is held in memory, which has the coordinates
+// of the texture and is reused for the next one, so fn must not retain it. An error returned by fn stops decoding
+// and is returned.
+func DecodeBands(r io.Reader, fn func(band image.Image) error) error {
+	return new(Decoder).DecodeBands(r, fn)
+}
+
+// WriteNRGBA reads a dds file from r and writes the decoded pixels to w as 8 bit non-premultiplied RGBA, row by row
+// without padding, so that large textures can be piped into an encoder or a hash without holding the whole image.
+func WriteNRGBA(r io.Reader, w io.Writer) error {
+	return new(Decoder).WriteNRGBA(r, w)
+}
+
+// DecodeBands reads a dds file from r like the package level DecodeBands.
+func (d *Decoder) DecodeBands(r io.Reader, fn func(band image.Image) error) error {
+	return d.DecodeBandsContext(context.Background(), r, fn)
+}
+
+// DecodeBandsContext reads a dds file from r like DecodeBands, but stops with ctx.Err() once ctx is done, see
+// DecodeContext.
+func (d *Decoder) DecodeBandsContext(ctx context.Context, r io.Reader, fn func(band image.Image) error) error {
+	h, err := d.reset(r)
+	if err != nil {
+		return err
+	}
+	if err = d.allocate(h, image.Rect(0, 0, int(h.Width), 4)); err != nil {
+		return err
+	}
+	return d.locate(d.d.DecodeBands(d.reader(ctx, r, h), fn))
+}
+
+// WriteNRGBA reads a dds file from r like the package level WriteNRGBA.
+func (d *Decoder) WriteNRGBA(r io.Reader, w io.Writer) error {
+	return d.WriteNRGBAContext(context.Background(), r, w)
+}
+
+// WriteNRGBAContext reads a dds file from r like WriteNRGBA, but stops with ctx.Err() once ctx is done, see
+// DecodeContext.
+func (d *Decoder) WriteNRGBAContext(ctx context.Context, r io.Reader, w io.Writer) error {
+	var converted *image.NRGBA
+	return d.DecodeBandsContext(ctx, r, func(band image.Image) error {
+		nrgba, ok := band.(*image.NRGBA)
+		if !ok {
+			if converted == nil || converted.Rect.Dx() != band.Bounds().Dx() {
+				converted = image.NewNRGBA(image.Rect(0, 0, band.Bounds().Dx(), 4))
+			}
+			nrgba = converted.SubImage(image.Rectangle{Max: band.Bounds().Size()}).(*image.NRGBA)
+			draw.Draw(nrgba, nrgba.Rect, band, band.Bounds().Min, draw.Src)
+		}
+		_, err := w.Write(nrgba.Pix[:nrgba.Rect.Dy()*nrgba.Stride])
+		return err
+	})
+}
@@ -0,0 +1,46 @@
+package dds
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader fails to read once its context is done. As the decoders read the texture data row by row, this
+// stops decoding between two rows of blocks or pixels.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// contextReadSeeker is a contextReader that keeps the ability to seek, which is used to skip data.
+type contextReadSeeker struct {
+	contextReader
+	s io.Seeker
+}
+
+func (r *contextReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.s.Seek(offset, whence)
+}
+
+// withContext returns a reader that reads from r until ctx is done. Contexts that are never done, like
+// context.Background(), return r itself, so that they add no overhead.
+func withContext(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return r
+	}
+	c := contextReader{ctx: ctx, r: r}
+	if s, ok := r.(io.Seeker); ok {
+		return &contextReadSeeker{contextReader: c, s: s}
+	}
+	return &c
+}
@@ -0,0 +1,70 @@
+package dds
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cancelReader cancels its context after a number of reads
+type cancelReader struct {
+	io.Reader
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	if r.reads--; r.reads == 0 {
+		r.cancel()
+	}
+	return r.Reader.Read(p)
+}
+
+func TestDecodeContext(t *testing.T) {
+	file := newTexture("DXT1", 1024, 16, make([]byte, 256*4*8))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := DecodeContext(ctx, bytes.NewReader(file))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the header takes one read, every row of blocks another
+	ctx, cancel = context.WithCancel(context.Background())
+	rd := bytes.NewReader(file)
+	_, err = DecodeContext(ctx, &cancelReader{Reader: rd, cancel: cancel, reads: 3})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2*256*8, rd.Len(), "decoding stops after the second row of blocks")
+
+	img, err := DecodeContext(context.Background(), bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 1024, 16), img.Bounds())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var d Decoder
+	img, err = d.DecodeRegionContext(ctx, bytes.NewReader(file), image.Rect(0, 8, 4, 12))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 8, 4, 12), img.Bounds())
+}
+
+func TestFile_DecodeContext(t *testing.T) {
+	file := newTexture("DXT1", 8, 8, make([]byte, 32))
+	f, err := Open(bytes.NewReader(file))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = f.DecodeContext(ctx, f.Surfaces[0])
+	assert.NoError(t, err)
+
+	cancel()
+	_, err = f.DecodeContext(ctx, f.Surfaces[0])
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = f.DecodeRegionContext(ctx, f.Surfaces[0], image.Rect(4, 4, 8, 8))
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = new(Decoder).ReadTextureContext(ctx, bytes.NewReader(file))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,238 @@
+package dds
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+
+	"github.com/funatsufumiya/dds-simd/decoder"
+	"github.com/funatsufumiya/dds-simd/decoder/dxt"
+	"github.com/funatsufumiya/dds-simd/decoder/uncompressed"
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// Decoder decodes a sequence of dds files and reuses its decoding state and buffers between them, so that
+// decoding does not allocate per block. Its fields configure the decoding, the zero value is ready to use and
+// decodes like the package level functions. A Decoder must not be used concurrently.
+type Decoder struct {
+	// Profile selects how the palettes of compressed textures are interpolated, see dxt.Profile.
+	Profile dxt.Profile
+
+	// ToneMap converts float textures to 8 bit. If it is hdr.ToneMapNone, they are decoded into an *hdr.Image.
+	ToneMap hdr.ToneMap
+
+	// Signed selects how signed normalized channels of bump maps and SNORM textures are mapped to the unsigned
+	// channels of the image, see uncompressed.SignedMapping.
+	Signed uncompressed.SignedMapping
+
+	// HeaderMode selects whether malformed headers are rejected or repaired, see header.Mode.
+	HeaderMode header.Mode
+
+	// Limits bound the size of the accepted textures and of the images they are decoded into before anything is
+	// allocated for them. If nil, header.DefaultLimits are used.
+	Limits *header.Limits
+
+	// Progress is called while the texture data is read, after every row of blocks or pixels, e.g. to show a
+	// progress bar. If nil, no progress is reported. Only decoding reports progress, as there is no encoder.
+	Progress func(Progress)
+
+	d        decoder.Decoder
+	offset   int64            // size of the header, the offset of the texture data
+	warnings []header.Warning // repairs of the last header
+}
+
+// DecodeConfig reads the header of a dds file from r like the package level DecodeConfig.
+func (d *Decoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := d.header(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return config(h, d.ToneMap)
+}
+
+// Decode reads a dds file from r like the package level Decode.
+func (d *Decoder) Decode(r io.Reader) (image.Image, error) {
+	return d.DecodeContext(context.Background(), r)
+}
+
+// DecodeContext reads a dds file from r like Decode, but stops with ctx.Err() once ctx is done. The context is
+// checked before every read of the texture data, which happens between rows of blocks or pixels.
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader) (image.Image, error) {
+	h, err := d.reset(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.allocate(h, image.Rect(0, 0, int(h.Width), int(h.Height))); err != nil {
+		return nil, err
+	}
+	img, err := d.d.Decode(d.reader(ctx, r, h))
+	return img, d.locate(err)
+}
+
+// DecodeRegion reads a dds file from r like the package level DecodeRegion. Unlike Decode, a stream ending after the
+// region is not an error.
+func (d *Decoder) DecodeRegion(r io.Reader, rect image.Rectangle) (image.Image, error) {
+	return d.DecodeRegionContext(context.Background(), r, rect)
+}
+
+// DecodeRegionContext reads a dds file from r like DecodeRegion, but stops with ctx.Err() once ctx is done, see
+// DecodeContext.
+func (d *Decoder) DecodeRegionContext(ctx context.Context, r io.Reader, rect image.Rectangle) (image.Image, error) {
+	h, err := d.header(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.allocate(h, rect); err != nil {
+		return nil, err
+	}
+	if err = d.prepare(h, h.Size()); err != nil {
+		return nil, err
+	}
+	img, err := d.d.DecodeRegion(d.reader(ctx, r, h), rect)
+	return img, d.locate(err)
+}
+
+// DecodeTo reads a dds file from r and decodes it into dst at dst.Bounds().Min, see DecodeAt.
+func (d *Decoder) DecodeTo(r io.Reader, dst draw.Image) error {
+	return d.DecodeAt(r, dst, dst.Bounds().Min)
+}
+
+// DecodeAt reads a dds file from r and decodes it into dst with the top left pixel of the texture at p, e.g. to
+// decode textures straight into an atlas. If dst does not cover the texture at p, an error wrapping
+// header.ErrBounds is returned before the texture data is read. Apart from parsing the header, decoding compressed
+// textures into an *image.NRGBA does not allocate.
+func (d *Decoder) DecodeAt(r io.Reader, dst draw.Image, p image.Point) error {
+	return d.DecodeAtContext(context.Background(), r, dst, p)
+}
+
+// DecodeAtContext reads a dds file from r like DecodeAt, but stops with ctx.Err() once ctx is done, see
+// DecodeContext. The pixels decoded until then are kept in dst.
+func (d *Decoder) DecodeAtContext(ctx context.Context, r io.Reader, dst draw.Image, p image.Point) error {
+	h, err := d.reset(r)
+	if err != nil {
+		return err
+	}
+	return d.locate(d.d.DecodeAt(d.reader(ctx, r, h), dst, p))
+}
+
+// Warnings returns the repairs applied to the header of the last file if HeaderMode is header.Lenient.
+func (d *Decoder) Warnings() []header.Warning {
+	return d.warnings
+}
+
+// header reads the header from r with the configured mode and checks it against the limits.
+func (d *Decoder) header(r io.Reader) (*header.Header, error) {
+	h, err := header.ReadWith(r, &header.Options{Mode: d.HeaderMode})
+	if err != nil {
+		d.warnings = nil
+		return nil, err
+	}
+	d.warnings = h.Warnings
+
+	if err = d.limits().Check(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// limits returns the configured limits, header.DefaultLimits if there are none.
+func (d *Decoder) limits() *header.Limits {
+	if d.Limits == nil {
+		return &header.DefaultLimits
+	}
+	return d.Limits
+}
+
+// allocate checks that a new image holding the part of the texture h within rect stays within the byte limit,
+// before the decoders allocate it without having read any data.
+func (d *Decoder) allocate(h *header.Header, rect image.Rectangle) error {
+	c, err := config(h, d.ToneMap)
+	if err != nil {
+		return nil // the decoders report unsupported formats
+	}
+	size := rect.Intersect(image.Rect(0, 0, c.Width, c.Height)).Size()
+	return d.limits().CheckBytes(uint64(size.X) * uint64(size.Y) * bytesPerPixel(c.ColorModel))
+}
+
+// bytesPerPixel returns the size of a pixel of the images created for textures with the color model m.
+func bytesPerPixel(m color.Model) uint64 {
+	if _, ok := m.(color.Palette); ok {
+		return 1
+	}
+	switch m {
+	case hdr.ColorModel:
+		return 16
+	case color.NRGBA64Model, color.RGBA64Model:
+		return 8
+	case color.Gray16Model, color.Alpha16Model:
+		return 2
+	case color.GrayModel, color.AlphaModel:
+		return 1
+	}
+	return 4
+}
+
+// reset reads the header from r and prepares the decoder for it.
+func (d *Decoder) reset(r io.Reader) (*header.Header, error) {
+	h, err := d.header(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.available(r, h); err != nil {
+		return nil, err
+	}
+	return h, d.prepare(h, h.Size())
+}
+
+// reader wraps r, from which the decoder prepared for the texture h reads its first surface, so that reading stops
+// once ctx is done and the progress is reported.
+func (d *Decoder) reader(ctx context.Context, r io.Reader, h *header.Header) io.Reader {
+	var surfaces []header.Surface
+	if d.Progress != nil {
+		if first, err := h.FirstSurface(); err == nil {
+			surfaces, _ = h.SurfaceHeader(first).Surfaces() // only the first slice of volume textures is decoded
+		}
+	}
+	return withContext(ctx, d.withProgress(r, h, surfaces))
+}
+
+// prepare sets up the decoder for the texture described by h, whose data starts at offset in the file.
+func (d *Decoder) prepare(h *header.Header, offset int64) error {
+	dec, err := decoder.Reset(d.d, h, &decoder.Options{Profile: d.Profile, ToneMap: d.ToneMap, Signed: d.Signed})
+	if err != nil {
+		return err
+	}
+	d.d = dec
+	d.offset = offset
+	return nil
+}
+
+// available checks up front that the texture h stores a surface and that r still holds the first one, if r reports
+// its remaining length like a *bytes.Reader does. Otherwise, missing data is reported while decoding.
+func (d *Decoder) available(r io.Reader, h *header.Header) error {
+	s, err := h.FirstSurface()
+	if errors.Is(err, header.ErrUnsupported) {
+		return nil // the decoders report unsupported formats
+	} else if err != nil {
+		return err
+	}
+	if l, ok := r.(interface{ Len() int }); ok && int64(l.Len()) < s.Size {
+		return &header.TruncatedError{Section: s.String(), Offset: h.Size(), Expected: s.Size, Received: int64(l.Len())}
+	}
+	return nil
+}
+
+// locate moves the offset of a *header.TruncatedError returned by the texture decoders, which is relative to the
+// texture data, to the position in the file.
+func (d *Decoder) locate(err error) error {
+	var t *header.TruncatedError
+	if errors.As(err, &t) {
+		t.Offset += d.offset
+	}
+	return err
+}
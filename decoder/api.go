@@ -1,12 +1,13 @@
 package decoder
 
 import (
-	"fmt"
 	"image"
+	"image/draw"
 	"io"
 
 	"github.com/funatsufumiya/dds-simd/decoder/dxt"
 	"github.com/funatsufumiya/dds-simd/decoder/uncompressed"
+	"github.com/funatsufumiya/dds-simd/hdr"
 	"github.com/funatsufumiya/dds-simd/header"
 )
 
@@ -14,31 +15,73 @@ import (
 type Decoder interface {
 	// Decode takes the header-less reader and tries to read an parse the image-data from it.
 	Decode(io.Reader) (image.Image, error)
+
+	// DecodeRegion works like Decode, but only decodes the part of the texture within the rectangle, clipped to
+	// the bounds of the texture. The data of the texture outside of it is skipped where possible.
+	DecodeRegion(io.Reader, image.Rectangle) (image.Image, error)
+
+	// DecodeTo decodes the texture into an existing image at the top left corner of its bounds, see DecodeAt.
+	DecodeTo(io.Reader, draw.Image) error
+
+	// DecodeAt decodes the texture into an existing image with the top left pixel of the texture at the point. If
+	// the image does not cover the texture there, a *header.BoundsError is returned before anything is read.
+	DecodeAt(io.Reader, draw.Image, image.Point) error
+
+	// DecodeBands decodes the texture four rows at a time and passes each band with the coordinates of the texture
+	// to the function, from top to bottom. The band is reused and must not be retained. An error returned by the
+	// function stops decoding and is returned.
+	DecodeBands(io.Reader, func(band image.Image) error) error
+}
+
+// Options configure the decoders. The zero value selects the defaults.
+type Options struct {
+	Profile dxt.Profile                // palette interpolation of compressed textures
+	ToneMap hdr.ToneMap                // conversion of float textures to 8 bit, none by default
+	Signed  uncompressed.SignedMapping // mapping of signed normalized channels, biased by default
 }
 
 // Find takes a parsed header.Header and tries to find a fitting Decoder or returns an error.
-func Find(h *header.Header) (d Decoder, err error) {
-	if h.PixelFlags.Is(header.DDPFFourCC) {
-		switch h.FourCC {
-		case 0:
-			if !h.PixelFlags.Has(header.DDPFRGB) {
-				err = fmt.Errorf("unsupported pixel format %x", h.PixelFlags)
-			} else {
-				d = uncompressed.New(h)
-			}
-
-		default:
-			switch h.FourCCString {
-			case "DXT1", "DXT2", "DXT3", "DXT4", "DXT5":
-				d, err = dxt.New(h.FourCCString, int(h.Width), int(h.Height))
-
-			default:
-				err = fmt.Errorf("texture with compression '%v' is unsupported", h.FourCC)
-			}
+func Find(h *header.Header) (Decoder, error) {
+	return Reset(nil, h, nil)
+}
+
+// Reset reuses the Decoder prev for the texture described by h, keeping its decoding state and buffers, if it is
+// able to decode it. Otherwise, or if prev is nil, it finds a new Decoder like Find. The options may be nil.
+func Reset(prev Decoder, h *header.Header, o *Options) (d Decoder, err error) {
+	if o == nil {
+		o = new(Options)
+	}
+
+	switch {
+	case h.PixelFlags.Has(header.DDPFFourCC) && compressed(h.FourCCString):
+		if x, ok := prev.(*dxt.Decoder); ok {
+			err = x.Reset(h.FourCCString, int(h.Width), int(h.Height), o.Profile)
+			d = x
+		} else {
+			d, err = dxt.New(h.FourCCString, int(h.Width), int(h.Height), o.Profile)
+		}
+
+	case uncompressed.Supported(h):
+		u, ok := prev.(*uncompressed.Decoder)
+		if !ok {
+			u = new(uncompressed.Decoder)
 		}
-	} else {
-		err = fmt.Errorf("texture without compression '%v' is unsupported", h.PixelFlags)
+		u.ToneMap, u.Signed = o.ToneMap, o.Signed
+		u.Reset(h)
+		d = u
+
+	default:
+		err = header.NewFormatError(h, "")
 	}
 
 	return
 }
+
+// compressed returns if fourCC is one of the block compressed formats handled by the dxt package.
+func compressed(fourCC string) bool {
+	switch fourCC {
+	case "DXT1", "DXT2", "DXT3", "DXT4", "DXT5":
+		return true
+	}
+	return false
+}
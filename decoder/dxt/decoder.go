@@ -3,86 +3,252 @@
 package dxt
 
 import (
-	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"io"
 
 	. "github.com/funatsufumiya/dds-simd/decoder/dxt/internal"
+	"github.com/funatsufumiya/dds-simd/header"
 )
 
 type (
 	Decoder struct {
 		strategy
-		bounds image.Point
+		batch   *Batch
+		reader  *Reader
+		bounds  image.Point
+		profile Profile
+		band    *image.NRGBA // reused by DecodeBands
 	}
 
 	strategy interface {
 		New(bounds image.Rectangle) draw.Image
 		BlockSize() byte
 		DecodeBlock(buffer []byte)
-		Pixel(index byte) color.Color
-		PixelBlock() [16]color.Color
+		Pixel(index byte) color.NRGBA
+		PixelBlock() [16]color.NRGBA
+		Colors() [4]color.NRGBA
 	}
 )
 
-func New(fourCC string, width, height int) (*Decoder, error) {
-	decoder := &Decoder{bounds: image.Pt(width, height)}
+// modes maps the supported fourCC codes to the alpha mode of their blocks
+var modes = map[string]AlphaMode{
+	"DXT1": AlphaNone,
+	"DXT3": AlphaExplicit,
+	"DXT5": AlphaInterpolated,
+}
 
-	switch fourCC {
-	case "DXT1":
-		decoder.strategy = new(dxt1)
-	case "DXT3":
-		decoder.strategy = new(dxt3)
-	case "DXT5":
-		decoder.strategy = new(dxt5)
-	default:
-		return nil, fmt.Errorf("DXT type '%s' not supported", fourCC)
+// alphaMode returns the alpha mode of the DXT format fourCC or a *header.FormatError if it is not supported.
+func alphaMode(fourCC string) (AlphaMode, error) {
+	if mode, ok := modes[fourCC]; ok {
+		return mode, nil
+	}
+	if fourCC == "DXT2" || fourCC == "DXT4" {
+		return 0, &header.FormatError{FourCC: fourCC, Reason: "premultiplied alpha is not supported"}
 	}
+	return 0, &header.FormatError{FourCC: fourCC, Reason: "not a DXT format"}
+}
 
+// New creates a decoder for textures of the given DXT format and size. The optional profile selects how the
+// palettes are interpolated, ProfileD3D10 is used if it is omitted. An unknown profile is reported as an error
+// wrapping ErrProfile.
+func New(fourCC string, width, height int, profile ...Profile) (*Decoder, error) {
+	decoder := new(Decoder)
+	if err := decoder.Reset(fourCC, width, height, profile...); err != nil {
+		return nil, err
+	}
 	return decoder, nil
 }
 
+// Reset prepares the decoder for another texture, as if it was created with New. The decoding state and all
+// buffers are kept if the block format and profile stay the same, so that decoding many textures does not
+// allocate.
+func (d *Decoder) Reset(fourCC string, width, height int, profile ...Profile) error {
+	mode, err := alphaMode(fourCC)
+	if err != nil {
+		return err
+	}
+	p, err := selectProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	d.bounds = image.Pt(width, height)
+	if d.batch != nil && d.batch.Mode() == mode && d.profile == p {
+		return nil
+	}
+
+	d.strategy = newStrategy(mode, p)
+	d.profile = p
+	d.batch = NewBatch(mode, Rules(p))
+	d.reader = NewReader(nil, d.BlockSize())
+	return nil
+}
+
+// newStrategy returns the scalar decoder of single blocks with the given alpha mode.
+func newStrategy(mode AlphaMode, p Profile) strategy {
+	rules := Rules(p)
+	switch mode {
+	case AlphaExplicit:
+		return &dxt3{ColorDecoder: ColorDecoder{Mode: mode, Rules: rules}}
+	case AlphaInterpolated:
+		return &dxt5{ColorDecoder: ColorDecoder{Mode: mode, Rules: rules}}
+	default:
+		return &dxt1{ColorDecoder: ColorDecoder{Mode: mode, Rules: rules}}
+	}
+}
+
 // Decode decodes from r and returns a new image.Image as before.
 func (d *Decoder) Decode(r io.Reader) (image.Image, error) {
-       bounds := image.Rectangle{Max: d.bounds}
-       img := d.New(bounds)
-       if bounds.Empty() {
-	       return img, nil
-       }
-       err := d.DecodeTo(r, img)
-       if err != nil {
-	       return nil, err
-       }
-       return img, nil
+	bounds := image.Rectangle{Max: d.bounds}
+	img := d.New(bounds)
+	if bounds.Empty() {
+		return img, nil
+	}
+	if err := d.DecodeTo(r, img); err != nil {
+		return nil, err
+	}
+	return img, nil
 }
 
-// DecodeTo decodes from r and writes the result into dst (must be at least bounds size).
-// This allows memory reuse and avoids unnecessary allocations.
+// DecodeTo decodes from r and writes the result into dst at dst.Bounds().Min, see DecodeAt.
 func (d *Decoder) DecodeTo(r io.Reader, dst draw.Image) error {
-       bounds := image.Rectangle{Max: d.bounds}
-       if bounds.Empty() {
-	       return nil
-       }
-       rd := NewReader(r, d.BlockSize())
-       for h := 0; h < d.bounds.Y; h += 4 {
-	       for w := 0; w < d.bounds.X; w += 4 {
-		       buffer, err := rd.Read()
-		       if err != nil {
-			       return err
-		       }
-		       d.DecodeBlock(buffer)
-		       blockColors := d.PixelBlock()
-		       for y := 0; y < 4; y++ {
-			       for x := 0; x < 4; x++ {
-				       pxIndex := x + y*4
-				       if w+x < d.bounds.X && h+y < d.bounds.Y {
-					       dst.Set(w+x, h+y, blockColors[pxIndex])
-				       }
-			       }
-		       }
-	       }
-       }
-       return nil
+	return d.DecodeAt(r, dst, dst.Bounds().Min)
+}
+
+// DecodeAt decodes from r and writes the result into dst with the top left pixel of the texture at p, e.g. to
+// decode textures straight into an atlas. If dst does not cover the texture at p, a *header.BoundsError is returned
+// before anything is read.
+// This allows memory reuse and avoids unnecessary allocations: once the decoder is set up, decoding into an
+// *image.NRGBA does not allocate at all.
+//
+// The blocks are decoded row by row in batches of up to BatchSize blocks, see Batch.
+func (d *Decoder) DecodeAt(r io.Reader, dst draw.Image, p image.Point) error {
+	if err := header.CheckBounds(dst.Bounds(), p, d.bounds.X, d.bounds.Y); err != nil {
+		return err
+	}
+	bounds := image.Rectangle{Max: d.bounds}
+	if bounds.Empty() {
+		return nil
+	}
+	d.reader.Reset(r)
+	defer d.reader.Reset(nil)
+
+	columns := (d.bounds.X + 3) / 4
+	for y := 0; y < d.bounds.Y; y += 4 {
+		if err := d.readRow(dst, p, 0, columns, y); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeRegion decodes only the blocks covering rect and returns the part of the texture within rect, clipped to
+// the bounds of the texture. The image keeps the coordinates of the texture like image.NRGBA.SubImage does. The
+// blocks before the region and between its rows are skipped, by seeking if r is an io.Seeker, and the blocks after
+// it are not read.
+func (d *Decoder) DecodeRegion(r io.Reader, rect image.Rectangle) (image.Image, error) {
+	rect = rect.Intersect(image.Rectangle{Max: d.bounds})
+	img := d.New(rect)
+	if rect.Empty() {
+		return img, nil
+	}
+	d.reader.Reset(r)
+	defer d.reader.Reset(nil)
+
+	columns, size := (d.bounds.X+3)/4, int64(d.BlockSize())
+	first, last := rect.Min.X/4, (rect.Max.X+3)/4 // columns of blocks covering the region
+	skip := int64(rect.Min.Y/4*columns + first)
+	for y := rect.Min.Y / 4 * 4; y < rect.Max.Y; y += 4 {
+		if err := d.reader.Skip(skip * size); err != nil {
+			return nil, d.truncated(err)
+		}
+		if err := d.readRow(img, image.Point{}, first, last, y); err != nil {
+			return nil, err
+		}
+		skip = int64(columns - last + first)
+	}
+	return img, nil
+}
+
+// DecodeBands decodes from r one row of blocks at a time and passes each band of up to four rows of pixels to fn,
+// from top to bottom, so that large textures can be processed without holding the whole image, e.g. to hash or
+// encode it. The band is an *image.NRGBA with the coordinates of the texture, which is reused for the next band and
+// must not be retained. An error returned by fn stops decoding and is returned.
+func (d *Decoder) DecodeBands(r io.Reader, fn func(band image.Image) error) error {
+	if d.bounds.X <= 0 || d.bounds.Y <= 0 {
+		return nil
+	}
+	if d.band == nil || d.band.Stride != 4*d.bounds.X {
+		d.band = image.NewNRGBA(image.Rect(0, 0, d.bounds.X, 4))
+	}
+	pix := d.band.Pix[:cap(d.band.Pix)]
+	d.reader.Reset(r)
+	defer d.reader.Reset(nil)
+
+	columns := (d.bounds.X + 3) / 4
+	for y := 0; y < d.bounds.Y; y += 4 {
+		rows := min(4, d.bounds.Y-y)
+		d.band.Rect = image.Rect(0, y, d.bounds.X, y+rows)
+		d.band.Pix = pix[:rows*d.band.Stride]
+		if err := d.readRow(d.band, image.Point{}, 0, columns, y); err != nil {
+			return err
+		}
+		if err := fn(d.band); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRow decodes the blocks from the column first up to last of the row of blocks starting at the pixel row y
+// into dst, with the texture placed at the point at.
+func (d *Decoder) readRow(dst draw.Image, at image.Point, first, last, y int) error {
+	for x := first; x < last; x += BatchSize {
+		blocks, err := d.reader.ReadBlocks(min(BatchSize, last-x))
+		if err != nil {
+			return d.truncated(err)
+		}
+		n := d.batch.Decode(blocks)
+		d.write(dst, at, x*4, y, n)
+	}
+	return nil
+}
+
+// truncated converts io.ErrUnexpectedEOF into a *header.TruncatedError about the blocks of the texture.
+func (d *Decoder) truncated(err error) error {
+	if err == io.ErrUnexpectedEOF {
+		size := int64(((d.bounds.X+3)/4)*((d.bounds.Y+3)/4)) * int64(d.BlockSize())
+		return &header.TruncatedError{Section: "blocks", Expected: size, Received: d.reader.Count()}
+	}
+	return err
+}
+
+// write copies the first n decoded blocks of the batch into dst, starting at the pixel x, y of the texture placed at
+// the point at. Pixels outside the bounds of the texture or of dst are skipped.
+func (d *Decoder) write(dst draw.Image, at image.Point, x, y, n int) {
+	clip := dst.Bounds().Sub(at).Intersect(image.Rectangle{Max: d.bounds})
+	top, bottom := max(0, clip.Min.Y-y), min(4, clip.Max.Y-y)
+	nrgba, _ := dst.(*image.NRGBA)
+	left, right := max(0, clip.Min.X-x), min(n*4, clip.Max.X-x) // pixel columns of the batch within clip
+	if left >= right {
+		return
+	}
+	for py := top; py < bottom; py++ {
+		if nrgba == nil {
+			for px := left; px < right; px++ {
+				dst.Set(at.X+x+px, at.Y+y+py, d.batch.Pixels[px/4][px%4+py*4])
+			}
+			continue
+		}
+		// the pixel row is written across all blocks, so that the stores are sequential
+		row := nrgba.Pix[nrgba.PixOffset(at.X+x+left, at.Y+y+py):]
+		row = row[:(right-left)*4]
+		for px := left; px < right; px++ {
+			c := d.batch.Pixels[px/4][px%4+py*4]
+			row[0], row[1], row[2], row[3] = c.R, c.G, c.B, c.A
+			row = row[4:]
+		}
+	}
 }
@@ -2,9 +2,17 @@ package dxt
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math/rand"
 	"testing"
+	"testing/iotest"
 
+	"github.com/funatsufumiya/dds-simd/header"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,3 +41,418 @@ func TestDecoderBatchDXT5(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, image.Rect(0, 0, 4, 4), img.Bounds())
 }
+
+// decodeScalar decodes data block by block through the strategy, as reference for the batch decoding.
+func decodeScalar(d *Decoder, data []byte) image.Image {
+	img := d.New(image.Rectangle{Max: d.bounds})
+	size := int(d.BlockSize())
+	for h := 0; h < d.bounds.Y; h += 4 {
+		for w := 0; w < d.bounds.X; w += 4 {
+			d.DecodeBlock(data[:size])
+			data = data[size:]
+			blockColors := d.PixelBlock()
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 4; x++ {
+					if w+x < d.bounds.X && h+y < d.bounds.Y {
+						img.Set(w+x, h+y, blockColors[x+y*4])
+					}
+				}
+			}
+		}
+	}
+	return img
+}
+
+// decodeScalarTo decodes data block by block through the strategy into the pixels of dst, which has the size of the
+// texture. Unlike decodeScalar it writes Pix directly like the batch decoding, so that both can be benchmarked.
+func decodeScalarTo(d *Decoder, data []byte, dst *image.NRGBA) {
+	size := int(d.BlockSize())
+	for h := 0; h < d.bounds.Y; h += 4 {
+		for w := 0; w < d.bounds.X; w += 4 {
+			d.DecodeBlock(data[:size])
+			data = data[size:]
+			block := d.PixelBlock()
+			for y := 0; y < min(4, d.bounds.Y-h); y++ {
+				row := dst.Pix[(h+y)*dst.Stride+w*4:]
+				for x := 0; x < min(4, d.bounds.X-w); x++ {
+					c := block[x+y*4]
+					row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+	}
+}
+
+// randomTexture returns random block data for a texture of the given size.
+func randomTexture(fourCC string, width, height int) []byte {
+	size := 16
+	if fourCC == "DXT1" {
+		size = 8
+	}
+	data := make([]byte, (width+3)/4*((height+3)/4)*size)
+	rand.New(rand.NewSource(int64(width * height))).Read(data)
+	return data
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	for _, fourCC := range []string{"DXT1", "DXT3", "DXT5"} {
+		for _, size := range []image.Point{{4, 4}, {7, 5}, {1030, 9}, {2, 1}} {
+			t.Run(fmt.Sprintf("%s %v", fourCC, size), func(t *testing.T) {
+				data := randomTexture(fourCC, size.X, size.Y)
+				d, err := New(fourCC, size.X, size.Y)
+				assert.NoError(t, err)
+
+				img, err := d.Decode(bytes.NewReader(data))
+				assert.NoError(t, err)
+				assert.Equal(t, decodeScalar(d, data), img)
+			})
+		}
+	}
+}
+
+func BenchmarkDecoder_DecodeTo(b *testing.B) {
+	const width, height = 1024, 1024
+	for _, fourCC := range []string{"DXT1", "DXT3", "DXT5"} {
+		data := randomTexture(fourCC, width, height)
+		d, _ := New(fourCC, width, height)
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+		b.Run("batch "+fourCC, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if err := d.DecodeTo(bytes.NewReader(data), img); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run("scalar "+fourCC, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				decodeScalarTo(d, data, img)
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeToAllocations(t *testing.T) {
+	for _, fourCC := range []string{"DXT1", "DXT3", "DXT5"} {
+		t.Run(fourCC, func(t *testing.T) {
+			data := randomTexture(fourCC, 1030, 9)
+			d, err := New(fourCC, 1030, 9)
+			assert.NoError(t, err)
+
+			img := d.New(image.Rect(0, 0, 1030, 9))
+			rd := bytes.NewReader(data)
+			allocs := testing.AllocsPerRun(10, func() {
+				rd.Reset(data)
+				assert.NoError(t, d.Reset(fourCC, 1030, 9))
+				assert.NoError(t, d.DecodeTo(rd, img))
+			})
+			assert.Zero(t, allocs)
+		})
+	}
+}
+
+func TestDecoder_PixelBlockAllocations(t *testing.T) {
+	data := randomTexture("DXT5", 4, 4)
+	d, err := New("DXT5", 4, 4)
+	assert.NoError(t, err)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		d.DecodeBlock(data)
+		_ = d.PixelBlock()
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestDecoder_Reset(t *testing.T) {
+	d, err := New("DXT1", 4, 4)
+	assert.NoError(t, err)
+
+	data := randomTexture("DXT5", 7, 5)
+	assert.NoError(t, d.Reset("DXT5", 7, 5))
+	img, err := d.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, decodeScalar(d, data), img)
+
+	var formatErr *header.FormatError
+	for fourCC, reason := range map[string]string{
+		"DXT2": "premultiplied alpha is not supported",
+		"DXT4": "premultiplied alpha is not supported",
+		"ATI2": "not a DXT format",
+	} {
+		err = d.Reset(fourCC, 4, 4)
+		if assert.ErrorAs(t, err, &formatErr, fourCC) {
+			assert.Equal(t, &header.FormatError{FourCC: fourCC, Reason: reason}, formatErr)
+		}
+		assert.ErrorIs(t, err, header.ErrUnsupported)
+
+		_, err = NewImage(bytes.NewReader(nil), fourCC, 4, 4)
+		assert.ErrorAs(t, err, &formatErr, fourCC)
+		assert.Equal(t, reason, formatErr.Reason)
+	}
+}
+
+func TestDecoder_Golden(t *testing.T) {
+	var (
+		white       = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+		black       = color.NRGBA{A: 255}
+		transparent = color.NRGBA{}
+	)
+
+	var tests = map[string]struct {
+		fourCC string
+		block  []byte
+		row    [4]color.NRGBA // first row
+		alpha  [16]byte       // alpha of all pixels, if the format has alpha
+	}{
+		"four colors": {
+			fourCC: "DXT1",
+			block:  []byte{0xFF, 0xFF, 0x00, 0x00, 0xE4, 0xE4, 0xE4, 0xE4},
+			row:    [4]color.NRGBA{white, black, {R: 170, G: 170, B: 170, A: 255}, {R: 85, G: 85, B: 85, A: 255}},
+		},
+		"four colors with exact expansion": {
+			fourCC: "DXT1",
+			block:  []byte{0xE3, 0x18, 0x00, 0x00, 0xE4, 0xE4, 0xE4, 0xE4},
+			row: [4]color.NRGBA{
+				{R: 25, G: 28, B: 25, A: 255},
+				black,
+				{R: 17, G: 19, B: 17, A: 255},
+				{R: 8, G: 9, B: 8, A: 255},
+			},
+		},
+		"three colors": {
+			fourCC: "DXT1",
+			block:  []byte{0x1F, 0x00, 0x00, 0xF8, 0xE4, 0xE4, 0xE4, 0xE4},
+			row: [4]color.NRGBA{
+				{B: 255, A: 255},
+				{R: 255, A: 255},
+				{R: 128, B: 128, A: 255},
+				transparent,
+			},
+		},
+		"four colors with c0 <= c1 in DXT3": {
+			fourCC: "DXT3",
+			block: []byte{
+				0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+				0x00, 0x00, 0xFF, 0xFF, 0xE4, 0xE4, 0xE4, 0xE4,
+			},
+			row:   [4]color.NRGBA{black, white, {R: 85, G: 85, B: 85, A: 255}, {R: 170, G: 170, B: 170, A: 255}},
+			alpha: [16]byte{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		"four colors with c0 == c1 in DXT5": {
+			fourCC: "DXT5",
+			block: []byte{
+				0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x1F, 0x00, 0x1F, 0x00, 0xE4, 0xE4, 0xE4, 0xE4,
+			},
+			row:   [4]color.NRGBA{{B: 255, A: 255}, {B: 255, A: 255}, {B: 255, A: 255}, {B: 255, A: 255}},
+			alpha: [16]byte{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		"three colors with c0 == c1 in DXT1": {
+			fourCC: "DXT1",
+			block:  []byte{0x1F, 0x00, 0x1F, 0x00, 0xE4, 0xE4, 0xE4, 0xE4},
+			row:    [4]color.NRGBA{{B: 255, A: 255}, {B: 255, A: 255}, {B: 255, A: 255}, transparent},
+		},
+		"explicit alpha": {
+			fourCC: "DXT3",
+			block: []byte{
+				0x10, 0x32, 0x54, 0x76, 0x98, 0xBA, 0xDC, 0xFE,
+				0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			row:   [4]color.NRGBA{white, white, white, white},
+			alpha: [16]byte{0, 17, 34, 51, 68, 85, 102, 119, 136, 153, 170, 187, 204, 221, 238, 255},
+		},
+		"eight alpha values": {
+			fourCC: "DXT5",
+			block: []byte{
+				0xFF, 0x00, 0x88, 0xC6, 0xFA, 0x00, 0x00, 0x00,
+				0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			row:   [4]color.NRGBA{white, white, white, white},
+			alpha: [16]byte{255, 0, 219, 182, 146, 109, 73, 36, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		"six alpha values": {
+			fourCC: "DXT5",
+			block: []byte{
+				0x00, 0xFF, 0x88, 0xC6, 0xFA, 0x00, 0x00, 0x00,
+				0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			row:   [4]color.NRGBA{white, white, white, white},
+			alpha: [16]byte{0, 255, 51, 102, 153, 204, 0, 255},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := New(test.fourCC, 4, 4)
+			assert.NoError(t, err)
+			img, err := d.Decode(bytes.NewReader(test.block))
+			assert.NoError(t, err)
+
+			nrgba := img.(*image.NRGBA)
+			for i := 0; i < 16; i++ {
+				c := nrgba.NRGBAAt(i%4, i/4)
+				expected := test.row[i%4]
+				if test.fourCC != "DXT1" {
+					expected.A = test.alpha[i]
+				}
+				assert.Equal(t, expected, c, "pixel %d", i)
+			}
+		})
+	}
+}
+
+func TestDecoder_Profile(t *testing.T) {
+	block := []byte{0xFF, 0xFF, 0x00, 0x00, 0xE4, 0xE4, 0xE4, 0xE4}
+	var tests = map[Profile][2]color.NRGBA{
+		ProfileD3D10:  {{R: 170, G: 170, B: 170, A: 255}, {R: 85, G: 85, B: 85, A: 255}},
+		ProfileNVIDIA: {{R: 170, G: 175, B: 170, A: 255}, {R: 85, G: 80, B: 85, A: 255}},
+		ProfileAMD:    {{R: 171, G: 171, B: 171, A: 255}, {R: 84, G: 84, B: 84, A: 255}},
+		ProfileD3D9:   {{R: 170, G: 170, B: 170, A: 255}, {R: 85, G: 85, B: 85, A: 255}},
+	}
+
+	d, err := New("DXT1", 4, 4)
+	assert.NoError(t, err)
+	for profile, expected := range tests {
+		assert.NoError(t, d.Reset("DXT1", 4, 4, profile))
+		img, err := d.Decode(bytes.NewReader(block))
+		assert.NoError(t, err)
+
+		nrgba := img.(*image.NRGBA)
+		assert.Equal(t, expected[0], nrgba.NRGBAAt(2, 0), "profile %d", profile)
+		assert.Equal(t, expected[1], nrgba.NRGBAAt(3, 0), "profile %d", profile)
+
+		d.DecodeBlock(block)
+		assert.Equal(t, expected[0], d.Pixel(2), "profile %d", profile)
+	}
+}
+
+func TestDecoder_UnknownProfile(t *testing.T) {
+	_, err := New("DXT1", 4, 4, Profile(9))
+	assert.ErrorIs(t, err, ErrProfile)
+
+	d, err := New("DXT1", 4, 4)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, d.Reset("DXT5", 4, 4, ProfileD3D9+1), ErrProfile)
+
+	_, err = NewImage(bytes.NewReader(nil), "DXT1", 4, 4, Profile(9))
+	assert.ErrorIs(t, err, ErrProfile)
+}
+
+func TestDecoder_DecodeOneByteReader(t *testing.T) {
+	data := randomTexture("DXT5", 1030, 9)
+	d, err := New("DXT5", 1030, 9)
+	assert.NoError(t, err)
+
+	img, err := d.Decode(iotest.OneByteReader(bytes.NewReader(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, decodeScalar(d, data), img)
+}
+
+func TestDecoder_DecodeTruncated(t *testing.T) {
+	data := randomTexture("DXT1", 8, 8)
+	d, err := New("DXT1", 8, 8)
+	assert.NoError(t, err)
+
+	for _, n := range []int{0, 8, 21} {
+		_, err = d.Decode(iotest.HalfReader(bytes.NewReader(data[:n])))
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		assert.ErrorContains(t, err, fmt.Sprintf("expected 32 bytes, got %d", n))
+	}
+}
+
+func TestDecoder_DecodeRegion(t *testing.T) {
+	data := randomTexture("DXT5", 30, 13)
+	d, err := New("DXT5", 30, 13)
+	assert.NoError(t, err)
+	full := decodeScalar(d, data).(*image.NRGBA)
+
+	for _, rect := range []image.Rectangle{image.Rect(5, 3, 22, 10), image.Rect(0, 12, 1, 13), image.Rect(-3, -3, 40, 40)} {
+		expected := full.SubImage(rect.Intersect(full.Rect))
+
+		r := bytes.NewReader(data)
+		img, err := d.DecodeRegion(r, rect)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Bounds(), img.Bounds())
+		for y := expected.Bounds().Min.Y; y < expected.Bounds().Max.Y; y++ {
+			for x := expected.Bounds().Min.X; x < expected.Bounds().Max.X; x++ {
+				assert.Equal(t, expected.At(x, y), img.At(x, y), "pixel %d, %d", x, y)
+			}
+		}
+
+		// without seeking, the blocks after the region are not read
+		r.Reset(data)
+		img, err = d.DecodeRegion(iotest.OneByteReader(r), rect)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Bounds(), img.Bounds())
+		clipped := expected.Bounds()
+		end := (clipped.Max.Y-1)/4*8*16 + (clipped.Max.X+3)/4*16
+		assert.Equal(t, len(data)-end, r.Len())
+	}
+
+	img, err := d.DecodeRegion(bytes.NewReader(data), image.Rect(40, 0, 50, 4))
+	assert.NoError(t, err)
+	assert.True(t, img.Bounds().Empty())
+
+	// the third row of blocks is missing
+	_, err = d.DecodeRegion(iotest.OneByteReader(bytes.NewReader(data[:260])), image.Rect(0, 9, 4, 10))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.ErrorContains(t, err, "expected 512 bytes, got 260")
+}
+
+func TestDecoder_DecodeAt(t *testing.T) {
+	data := randomTexture("DXT5", 7, 5)
+	d, err := New("DXT5", 7, 5)
+	assert.NoError(t, err)
+	expected := decodeScalar(d, data)
+
+	atlas := image.NewNRGBA(image.Rect(-2, -3, 20, 20))
+	assert.NoError(t, d.DecodeAt(bytes.NewReader(data), atlas, image.Pt(3, 4)))
+	for y := atlas.Rect.Min.Y; y < atlas.Rect.Max.Y; y++ {
+		for x := atlas.Rect.Min.X; x < atlas.Rect.Max.X; x++ {
+			c := color.NRGBA{}
+			if image.Pt(x-3, y-4).In(expected.Bounds()) {
+				c = expected.At(x-3, y-4).(color.NRGBA)
+			}
+			assert.Equal(t, c, atlas.NRGBAAt(x, y), "pixel %d, %d", x, y)
+		}
+	}
+
+	rgba := image.NewRGBA(image.Rect(10, 10, 17, 15))
+	assert.NoError(t, d.DecodeTo(bytes.NewReader(data), rgba))
+	assert.Equal(t, color.RGBAModel.Convert(expected.At(6, 4)), rgba.At(16, 14))
+
+	r := bytes.NewReader(data)
+	err = d.DecodeAt(r, atlas, image.Pt(15, 0))
+	var bounds *header.BoundsError
+	if assert.ErrorAs(t, err, &bounds) {
+		assert.Equal(t, header.BoundsError{Texture: image.Rect(15, 0, 22, 5), Dst: atlas.Rect}, *bounds)
+	}
+	assert.Equal(t, len(data), r.Len(), "nothing is read")
+}
+
+func TestDecoder_DecodeBands(t *testing.T) {
+	data := randomTexture("DXT5", 7, 9)
+	d, err := New("DXT5", 7, 9)
+	assert.NoError(t, err)
+	expected := decodeScalar(d, data)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 7, 9))
+	var bands []image.Rectangle
+	err = d.DecodeBands(bytes.NewReader(data), func(band image.Image) error {
+		bands = append(bands, band.Bounds())
+		draw.Draw(img, band.Bounds(), band, band.Bounds().Min, draw.Src)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []image.Rectangle{image.Rect(0, 0, 7, 4), image.Rect(0, 4, 7, 8), image.Rect(0, 8, 7, 9)}, bands)
+	assert.Equal(t, expected, img)
+
+	stop := errors.New("stop")
+	r := bytes.NewReader(data)
+	err = d.DecodeBands(r, func(image.Image) error { return stop })
+	assert.Equal(t, stop, err)
+	assert.Equal(t, len(data)-2*16, r.Len(), "decoding stops after the first row of blocks")
+}
@@ -18,13 +18,13 @@ func (d *dxt1) DecodeBlock(buffer []byte) {
 	d.BlockColor(buffer[0:8:8])
 }
 
-func (d *dxt1) Pixel(index byte) color.Color {
+func (d *dxt1) Pixel(index byte) color.NRGBA {
 	return d.PixelColor(index)
 }
 
 // PixelBlock returns a 4x4 block of colors (16 pixels) for the current block.
-func (d *dxt1) PixelBlock() [16]color.Color {
-	var out [16]color.Color
+func (d *dxt1) PixelBlock() [16]color.NRGBA {
+	var out [16]color.NRGBA
 	for i := 0; i < 16; i++ {
 		out[i] = d.Pixel(byte(i))
 	}
@@ -20,14 +20,14 @@ func (d *dxt3) DecodeBlock(buffer []byte) {
 	d.BlockColor(buffer[8:16:16])
 }
 
-func (d *dxt3) Pixel(index byte) color.Color {
+func (d *dxt3) Pixel(index byte) color.NRGBA {
 	alpha := ExtractIndex(d.alphaValues, index, 4) * 17
 	return d.PixelAlpha(index, alpha)
 }
 
 // PixelBlock returns a 4x4 block of colors (16 pixels) for the current block.
-func (d *dxt3) PixelBlock() [16]color.Color {
-	var out [16]color.Color
+func (d *dxt3) PixelBlock() [16]color.NRGBA {
+	var out [16]color.NRGBA
 	for i := 0; i < 16; i++ {
 		out[i] = d.Pixel(byte(i))
 	}
@@ -0,0 +1,129 @@
+package dxt
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	. "github.com/funatsufumiya/dds-simd/decoder/dxt/internal"
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// CacheSize is the number of decoded blocks kept by an Image.
+const CacheSize = 256
+
+// Image is an image.Image backed by the blocks of a compressed texture, which are only read and decoded when one of
+// their pixels is accessed. The most recently decoded blocks are cached, so that scanning the image row by row
+// decodes every block of a row of blocks once, as long as it is at most CacheSize blocks wide. The memory used by an
+// Image does not depend on the size of the texture.
+//
+// As At updates the cache, an Image must not be used concurrently. SubImage returns an image with a cache of its
+// own, which may be used concurrently with the original.
+type Image struct {
+	r       io.ReaderAt
+	rect    image.Rectangle // visible part of the texture
+	columns int             // blocks per row of the texture
+	mode    AlphaMode
+	profile Profile
+	decode  strategy
+	buffer  []byte
+	cache   *[CacheSize]cachedBlock
+	err     error
+}
+
+// cachedBlock holds the decoded pixels of the block with the index block-1, zero marks an empty entry
+type cachedBlock struct {
+	block  int
+	pixels [16]color.NRGBA
+}
+
+// NewImage creates an image for a texture of the given DXT format and size whose blocks are read from r, which
+// may e.g. be a *bytes.Reader or an *io.SectionReader of a file. The optional profile selects how the palettes are
+// interpolated, ProfileD3D10 is used if it is omitted. An unknown profile is reported as an error wrapping ErrProfile.
+func NewImage(r io.ReaderAt, fourCC string, width, height int, profile ...Profile) (*Image, error) {
+	mode, err := alphaMode(fourCC)
+	if err != nil {
+		return nil, err
+	}
+	p, err := selectProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{
+		r:       r,
+		rect:    image.Rect(0, 0, width, height),
+		columns: (width + 3) / 4,
+		mode:    mode,
+		profile: p,
+		decode:  newStrategy(mode, p),
+		buffer:  make([]byte, mode.BlockSize()),
+		cache:   new([CacheSize]cachedBlock),
+	}, nil
+}
+
+func (m *Image) ColorModel() color.Model { return color.NRGBAModel }
+
+func (m *Image) Bounds() image.Rectangle { return m.rect }
+
+func (m *Image) At(x, y int) color.Color {
+	return m.NRGBAAt(x, y)
+}
+
+// NRGBAAt returns the color of the pixel at (x, y), decoding its block if it is not cached. Pixels outside the
+// bounds and of blocks that cannot be read are transparent black, see Err.
+func (m *Image) NRGBAAt(x, y int) color.NRGBA {
+	if !(image.Point{X: x, Y: y}.In(m.rect)) {
+		return color.NRGBA{}
+	}
+	block := y/4*m.columns + x/4
+	c := &m.cache[block%CacheSize]
+	if c.block != block+1 {
+		if !m.read(block, &c.pixels) {
+			return color.NRGBA{}
+		}
+		c.block = block + 1
+	}
+	return c.pixels[x%4+y%4*4]
+}
+
+// read reads and decodes the block with the given index into pixels.
+func (m *Image) read(block int, pixels *[16]color.NRGBA) bool {
+	size := int64(len(m.buffer))
+	n, err := m.r.ReadAt(m.buffer, int64(block)*size)
+	if n < len(m.buffer) {
+		if err == io.EOF {
+			err = &header.TruncatedError{
+				Section:  fmt.Sprintf("block (%d, %d)", block%m.columns, block/m.columns),
+				Offset:   int64(block) * size,
+				Expected: size,
+				Received: int64(n),
+			}
+		}
+		if m.err == nil {
+			m.err = err
+		}
+		return false
+	}
+	m.decode.DecodeBlock(m.buffer)
+	*pixels = m.decode.PixelBlock()
+	return true
+}
+
+// SubImage returns an image representing the portion of the image m visible through r. It reads from the same
+// blocks, but has a cache of its own.
+func (m *Image) SubImage(r image.Rectangle) image.Image {
+	s := *m
+	s.rect = r.Intersect(m.rect)
+	s.buffer = make([]byte, len(m.buffer))
+	s.cache = new([CacheSize]cachedBlock)
+	s.decode = newStrategy(m.mode, m.profile)
+	return &s
+}
+
+// Err returns the first error that occurred while reading blocks, or nil. If the data of the texture is incomplete,
+// it is a *header.TruncatedError about the first block that could not be read, with an offset relative to the first
+// block.
+func (m *Image) Err() error {
+	return m.err
+}
@@ -0,0 +1,55 @@
+package dxt
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImage(t *testing.T) {
+	for _, fourCC := range []string{"DXT1", "DXT3", "DXT5"} {
+		data := randomTexture(fourCC, 1030, 9)
+		d, err := New(fourCC, 1030, 9, ProfileNVIDIA)
+		assert.NoError(t, err)
+		expected := decodeScalar(d, data).(*image.NRGBA)
+
+		img, err := NewImage(bytes.NewReader(data), fourCC, 1030, 9, ProfileNVIDIA)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Bounds(), img.Bounds())
+		assert.Equal(t, color.NRGBAModel, img.ColorModel())
+		for y := 0; y < 9; y++ {
+			for x := 0; x < 1030; x++ {
+				if !assert.Equal(t, expected.NRGBAAt(x, y), img.NRGBAAt(x, y), "%s pixel %d, %d", fourCC, x, y) {
+					return
+				}
+			}
+		}
+		assert.NoError(t, img.Err())
+
+		sub := img.SubImage(image.Rect(1020, 5, 1040, 20))
+		assert.Equal(t, image.Rect(1020, 5, 1030, 9), sub.Bounds())
+		assert.Equal(t, expected.At(1029, 8), sub.At(1029, 8))
+		assert.Equal(t, color.NRGBA{}, sub.At(0, 0), "outside of the sub image")
+	}
+}
+
+func TestImage_Truncated(t *testing.T) {
+	data := randomTexture("DXT1", 8, 8)
+	img, err := NewImage(bytes.NewReader(data[:20]), "DXT1", 8, 8)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, color.NRGBA{}, img.At(4, 0))
+	assert.Equal(t, color.NRGBA{}, img.At(4, 4))
+	assert.Equal(t, color.NRGBA{}, img.At(0, 4), "the error is kept")
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, img.Err(), &truncated) {
+		assert.Equal(t, header.TruncatedError{Section: "block (1, 1)", Offset: 24, Expected: 8, Received: 0}, *truncated)
+	}
+
+	_, err = NewImage(bytes.NewReader(data), "ATI2", 8, 8)
+	assert.ErrorIs(t, err, header.ErrUnsupported)
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"image/color"
+)
+
+// AlphaMode describes how a block stores its alpha channel.
+type AlphaMode byte
+
+// alpha modes of the DXT formats
+const (
+	AlphaNone         AlphaMode = iota // DXT1: 8 byte blocks, alpha only through the three color mode
+	AlphaExplicit                      // DXT2, DXT3: 16 byte blocks, 4 bit alpha per pixel
+	AlphaInterpolated                  // DXT4, DXT5: 16 byte blocks, two alpha endpoints and 3 bit indices
+)
+
+// BlockSize returns the size in bytes of a block using this alpha mode.
+func (m AlphaMode) BlockSize() int {
+	if m == AlphaNone {
+		return 8
+	}
+	return 16
+}
+
+// Batch decodes up to BatchSize consecutive blocks at once. Instead of interpolating every palette on its own,
+// the endpoints of all blocks are gathered into lanes and the palettes are interpolated with SIMD operations
+// across the whole batch. The indices are spread with SWAR operations, so the per pixel work left is a table lookup.
+//
+// Compared with the block decoders, which compute the palettes once per block and look every pixel up through its
+// index, a batch of DXT1 or DXT3 blocks decodes about 1.5 times and one of DXT5 blocks about 1.3 times as fast, see
+// BenchmarkBatch_Decode.
+type Batch struct {
+	Pixels [BatchSize][16]color.NRGBA // decoded pixels of every block in row-major order
+
+	mode  AlphaMode
+	rules Rules
+	size  int
+
+	four   [BatchSize]bool           // block uses the four color mode
+	alpha  [BatchSize]bool           // block uses the eight alpha mode
+	ends   [BatchSize][2]color.NRGBA // expanded color endpoints
+	ends4  endpointVector            // operands of the color kernel
+	q      colorVector               // correction term of the NVIDIA rules
+	colors colorVector               // interpolated color entries
+	a0, a1 alphaVector               // alpha endpoints, repeated for every alpha lane
+	alphas alphaVector               // interpolated alpha entries
+	tmp    alphaVector               // scratch space, large enough for both vectors
+	kernel *kernel                   // interpolation of the color entries with the rules
+	exp5   [32]byte                  // 5 bit channel values expanded with the rules
+	exp6   [64]byte                  // 6 bit channel values expanded with the rules
+}
+
+// NewBatch creates a batch decoder for blocks with the given alpha mode, using rules for the color palettes.
+func NewBatch(mode AlphaMode, rules Rules) *Batch {
+	b := &Batch{mode: mode, rules: rules, size: mode.BlockSize(), kernel: colorKernels[rules]()}
+	for v := range b.exp6 {
+		if v < len(b.exp5) {
+			b.exp5[v] = rules.expand5(byte(v))
+		}
+		b.exp6[v] = rules.expand6(byte(v))
+	}
+	return b
+}
+
+// Mode returns the alpha mode of the decoded blocks.
+func (b *Batch) Mode() AlphaMode {
+	return b.mode
+}
+
+// Decode decodes all blocks in data into Pixels and returns the number of decoded blocks. The data is consumed in
+// multiples of the block size and at most BatchSize blocks are decoded.
+func (b *Batch) Decode(data []byte) int {
+	n := min(len(data)/b.size, BatchSize)
+	if n == 0 {
+		return 0
+	}
+
+	b.gather(data, n)
+	b.interpolate()
+	for i := 0; i < n; i++ {
+		b.emit(i, data[i*b.size:(i+1)*b.size])
+	}
+	return n
+}
+
+// gather collects the endpoints of n blocks into the operands of the kernels. The color entry c3 swaps the
+// endpoints of c2, and negates the correction term of the NVIDIA rules.
+func (b *Batch) gather(data []byte, n int) {
+	for i := 0; i < n; i++ {
+		block := data[i*b.size : (i+1)*b.size]
+		c := block[b.size-8:]
+		v0 := uint16(c[0]) | uint16(c[1])<<8
+		v1 := uint16(c[2]) | uint16(c[3])<<8
+
+		b.four[i] = v0 > v1 || b.mode != AlphaNone // only DXT1 has a three color mode
+		r0, g0, b0 := split565(v0)
+		r1, g1, b1 := split565(v1)
+		e0 := [3]byte{b.exp5[r0], b.exp6[g0], b.exp5[b0]}
+		e1 := [3]byte{b.exp5[r1], b.exp6[g1], b.exp5[b1]}
+		b.ends[i] = [2]color.NRGBA{{e0[0], e0[1], e0[2], 255}, {e1[0], e1[1], e1[2], 255}}
+
+		in0, in1 := e0, e1
+		if b.rules == NVIDIA { // red and blue are interpolated on the raw values
+			in0[0], in0[2], in1[0], in1[2] = r0, b0, r1, b1
+			q := float32((int(e1[1]) - int(e0[1])) / 4)
+			b.q[colorLane(0, 1, i)], b.q[colorLane(1, 1, i)], b.q[colorLane(2, 1, i)] = q, -q, q
+		}
+		for ch := 0; ch < 3; ch++ {
+			f0, f1 := float32(in0[ch]), float32(in1[ch])
+			b.ends4[colorLane(0, ch, i)], b.ends4[colorLane(1, ch, i)] = f0, f1
+			b.ends4[colorLane(2, ch, i)], b.ends4[colorLane(3, ch, i)] = f0, f1
+		}
+
+		if b.mode == AlphaInterpolated {
+			b.alpha[i] = block[0] > block[1]
+			a0, a1 := float32(block[0]), float32(block[1])
+			for k := 0; k < alphaLanes; k++ {
+				b.a0[alphaLane(k, i)], b.a1[alphaLane(k, i)] = a0, a1
+			}
+		}
+	}
+}
+
+// interpolate computes all palette entries of the batch lane-wise. The lanes of blocks beyond those gathered hold
+// stale but finite values, so whole vectors are computed, which costs the same number of SIMD operations.
+func (b *Batch) interpolate() {
+	x, y := b.ends4[:len(b.colors)], b.ends4[3*BatchSize:]
+	b.kernel.apply(b.colors[:], x, y, b.q[:], b.tmp[:len(b.colors)])
+	if b.mode == AlphaInterpolated {
+		alphaKernel().apply(b.alphas[:], b.a0[:], b.a1[:], nil, b.tmp[:])
+	}
+}
+
+// emit assembles the palette of block i from the lanes and writes its pixels.
+func (b *Batch) emit(i int, block []byte) {
+	var pal [4]color.NRGBA
+	pal[0], pal[1] = b.ends[i][0], b.ends[i][1]
+	if b.four[i] {
+		pal[2] = b.color(0, i)
+		pal[3] = b.color(1, i)
+	} else {
+		pal[2] = b.color(2, i)
+		pal[3] = color.NRGBA{} // A: 0; important and implicit
+	}
+
+	px := &b.Pixels[i]
+	c := block[b.size-4:]
+	if b.mode == AlphaNone {
+		w := uint32(c[0]) | uint32(c[1])<<8 | uint32(c[2])<<16 | uint32(c[3])<<24
+		for p := range px {
+			px[p] = pal[w&3]
+			w >>= 2
+		}
+		return
+	}
+
+	var idx, aidx [16]byte
+	SpreadIndices(&idx, c, 2)
+	switch b.mode {
+	case AlphaExplicit:
+		SpreadIndices(&aidx, block[0:8], 4)
+		for p, ci := range idx {
+			px[p] = pal[ci&3]
+			px[p].A = aidx[p] * 17
+		}
+	case AlphaInterpolated:
+		av := [8]byte{block[0], block[1]}
+		if b.alpha[i] {
+			for k := 0; k < 6; k++ {
+				av[k+2] = byte(b.alphas[alphaLane(k, i)])
+			}
+		} else {
+			for k := 0; k < 4; k++ {
+				av[k+2] = byte(b.alphas[alphaLane(6+k, i)])
+			}
+			av[6], av[7] = 0, 255
+		}
+		SpreadIndices(&aidx, block[2:8], 3)
+		for p, ci := range idx {
+			px[p] = pal[ci&3]
+			px[p].A = av[aidx[p]&7]
+		}
+	}
+}
+
+// color returns the opaque interpolated color entry e of block i, see colorLane.
+func (b *Batch) color(e, i int) color.NRGBA {
+	return color.NRGBA{
+		R: byte(b.colors[colorLane(e, 0, i)]),
+		G: byte(b.colors[colorLane(e, 1, i)]),
+		B: byte(b.colors[colorLane(e, 2, i)]),
+		A: 255,
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// randomBlocks returns n random blocks of the given mode, where every other block has swapped endpoints so
+// that both the three and four color modes are covered.
+func randomBlocks(mode AlphaMode, n int) []byte {
+	rnd := rand.New(rand.NewSource(int64(mode)))
+	size := mode.BlockSize()
+	data := make([]byte, n*size)
+	rnd.Read(data)
+	for i := 0; i < n; i += 2 {
+		c := data[i*size+size-8:]
+		c[0], c[1], c[2], c[3] = c[2], c[3], c[0], c[1]
+	}
+	return data
+}
+
+// scalarBlock decodes a single block pixel by pixel as reference for the batch, the way the block decoders of the
+// dxt package do: the palettes are computed once per block and every pixel is looked up through its index.
+func scalarBlock(mode AlphaMode, rules Rules, block []byte) (px [16]color.NRGBA) {
+	cd := ColorDecoder{Mode: mode, Rules: rules}
+	cd.BlockColor(block[len(block)-8:])
+
+	var av [8]byte
+	if mode == AlphaInterpolated {
+		a0, a1 := block[0], block[1]
+		av = [8]byte{a0, a1, 0, 0, 0, 0, 0, 255}
+		if a0 > a1 {
+			for k := 0; k < 6; k++ {
+				av[k+2] = Weighted(float64(6-k), a0, float64(k+1), a1)
+			}
+		} else {
+			for k := 0; k < 4; k++ {
+				av[k+2] = Weighted(float64(4-k), a0, float64(k+1), a1)
+			}
+		}
+	}
+
+	for i := byte(0); i < 16; i++ {
+		px[i] = cd.PixelColor(i)
+		switch mode {
+		case AlphaExplicit:
+			px[i].A = ExtractIndex(block[0:8], i, 4) * 17
+		case AlphaInterpolated:
+			px[i].A = av[ExtractIndex(block[2:8], i, 3)]
+		}
+	}
+	return
+}
+
+func TestBatch_Decode(t *testing.T) {
+	for _, mode := range []AlphaMode{AlphaNone, AlphaExplicit, AlphaInterpolated} {
+		for _, rules := range []Rules{D3D10, NVIDIA, AMD, D3D9} {
+			t.Run(fmt.Sprintf("mode %d rules %d", mode, rules), func(t *testing.T) {
+				size := mode.BlockSize()
+				data := randomBlocks(mode, BatchSize+3)
+
+				b := NewBatch(mode, rules)
+				assert.Equal(t, BatchSize, b.Decode(data))
+				for i := 0; i < BatchSize; i++ {
+					assert.Equal(t, scalarBlock(mode, rules, data[i*size:(i+1)*size]), b.Pixels[i], "block %d", i)
+				}
+
+				rest := data[BatchSize*size:]
+				assert.Equal(t, 3, b.Decode(rest))
+				for i := 0; i < 3; i++ {
+					assert.Equal(t, scalarBlock(mode, rules, rest[i*size:(i+1)*size]), b.Pixels[i], "block %d", i)
+				}
+			})
+		}
+	}
+}
+
+func TestBatch_DecodeAllEndpoints(t *testing.T) {
+	// every 5 bit value paired with every other, for red/blue and green in both color modes
+	var data []byte
+	for v0 := uint16(0); v0 < 64; v0++ {
+		for v1 := uint16(0); v1 < 64; v1++ {
+			c0 := v0>>1<<11 | v0<<5 | v0>>1
+			c1 := v1>>1<<11 | v1<<5 | v1>>1
+			data = append(data, byte(c0), byte(c0>>8), byte(c1), byte(c1>>8), 0xE4, 0xE4, 0xE4, 0xE4)
+		}
+	}
+
+	for _, rules := range []Rules{D3D10, NVIDIA, AMD, D3D9} {
+		b := NewBatch(AlphaNone, rules)
+		for data := data; len(data) > 0; {
+			n := b.Decode(data)
+			for i := 0; i < n; i++ {
+				if expected := scalarBlock(AlphaNone, rules, data[i*8:(i+1)*8]); expected != b.Pixels[i] {
+					t.Fatalf("rules %d block %x: expected %v, got %v", rules, data[i*8:(i+1)*8], expected, b.Pixels[i])
+				}
+			}
+			data = data[n*8:]
+		}
+	}
+}
+
+func BenchmarkBatch_Decode(b *testing.B) {
+	for _, mode := range []AlphaMode{AlphaNone, AlphaExplicit, AlphaInterpolated} {
+		data := randomBlocks(mode, BatchSize)
+		batch := NewBatch(mode, D3D10)
+
+		b.Run(fmt.Sprintf("batch mode %d", mode), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				batch.Decode(data)
+			}
+		})
+
+		b.Run(fmt.Sprintf("scalar mode %d", mode), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			size := mode.BlockSize()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < BatchSize; j++ {
+					batch.Pixels[j] = scalarBlock(mode, D3D10, data[j*size:(j+1)*size])
+				}
+			}
+		})
+	}
+}
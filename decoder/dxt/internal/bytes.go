@@ -1,6 +1,9 @@
 package internal
 
-import "math"
+import (
+	"encoding/binary"
+	"math"
+)
 
 // ExtractIndex returns some bits form a byte array, being able to go across byte boundaries. The slice is interpreted
 // in ascending order. The length is interpreted as slicing the slice into fixed length items and the
@@ -43,3 +46,49 @@ func ExtractVector(bytes []byte, shift, length byte) byte {
 func Weighted(w0 float64, v0 byte, w1 float64, v1 byte) byte {
 	return byte(math.Round((w0*float64(v0) + w1*float64(v1)) / (w0 + w1)))
 }
+
+// SpreadIndices unpacks 16 packed indices of the given bit length from data into one byte each. Instead of
+// extracting every index on its own, the bits are spread eight at a time inside a 64 bit word (SWAR), so a whole
+// block needs only a handful of shifts and masks.
+//
+// Supported lengths are 2 (color indices), 3 (interpolated alpha indices) and 4 (explicit alpha values). The data
+// needs to hold at least 2*length bytes.
+func SpreadIndices(out *[16]byte, data []byte, length byte) {
+	var lo, hi uint64
+	switch length {
+	case 2:
+		lo = spread2(uint64(data[0]) | uint64(data[1])<<8)
+		hi = spread2(uint64(data[2]) | uint64(data[3])<<8)
+	case 3:
+		lo = spread3(uint64(data[0]) | uint64(data[1])<<8 | uint64(data[2])<<16)
+		hi = spread3(uint64(data[3]) | uint64(data[4])<<8 | uint64(data[5])<<16)
+	case 4:
+		lo = spread4(uint64(binary.LittleEndian.Uint32(data[0:4])))
+		hi = spread4(uint64(binary.LittleEndian.Uint32(data[4:8])))
+	default:
+		panic("internal: unsupported index length")
+	}
+	binary.LittleEndian.PutUint64(out[0:8], lo)
+	binary.LittleEndian.PutUint64(out[8:16], hi)
+}
+
+// spread2 moves eight 2 bit values of the lower 16 bits into the lowest bits of eight bytes.
+func spread2(x uint64) uint64 {
+	x = (x | x<<24) & 0x000000FF_000000FF
+	x = (x | x<<12) & 0x000F000F_000F000F
+	return (x | x<<6) & 0x03030303_03030303
+}
+
+// spread3 moves eight 3 bit values of the lower 24 bits into the lowest bits of eight bytes.
+func spread3(x uint64) uint64 {
+	x = (x | x<<20) & 0x00000FFF_00000FFF
+	x = (x | x<<10) & 0x003F003F_003F003F
+	return (x | x<<5) & 0x07070707_07070707
+}
+
+// spread4 moves eight 4 bit values of the lower 32 bits into the lowest bits of eight bytes.
+func spread4(x uint64) uint64 {
+	x = (x | x<<16) & 0x0000FFFF_0000FFFF
+	x = (x | x<<8) & 0x00FF00FF_00FF00FF
+	return (x | x<<4) & 0x0F0F0F0F_0F0F0F0F
+}
@@ -115,3 +115,22 @@ func TestExtract(t *testing.T) {
 		}
 	})
 }
+
+func TestSpreadIndices(t *testing.T) {
+	var in = []byte{
+		0b0010_0001, 0b1000_0100,
+		0b0011_1001, 0b1100_0110,
+		0b1101_1110, 0b0111_1011,
+		0b0000_1111, 0b1111_0000,
+	}
+
+	for _, length := range []byte{2, 3, 4} {
+		t.Run(fmt.Sprintf("in %ds", length), func(t *testing.T) {
+			var out [16]byte
+			SpreadIndices(&out, in, length)
+			for i := byte(0); i < 16; i++ {
+				assert.Equal(t, ExtractIndex(in, i, length), out[i], "index %d", i)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"github.com/stretchr/testify/assert"
 	"image/color"
+	"math"
 	"testing"
 )
 
@@ -17,8 +18,8 @@ func TestInterpolateColors(t *testing.T) {
 			in2: [2]byte{0b00010001, 0b10001100},
 			out: [4]color.NRGBA{
 				{R: 8, G: 4, B: 8, A: 255},
-				{R: 136, G: 128, B: 136, A: 255},
-				{R: 72, G: 66, B: 72, A: 255},
+				{R: 140, G: 130, B: 140, A: 255},
+				{R: 74, G: 67, B: 74, A: 255},
 				{R: 0, G: 0, B: 0, A: 0},
 			},
 		},
@@ -26,10 +27,10 @@ func TestInterpolateColors(t *testing.T) {
 			in1: [2]byte{0b00010000, 0b10000100},
 			in2: [2]byte{0b00100001, 0b00001000},
 			out: [4]color.NRGBA{
-				{R: 128, G: 128, B: 128, A: 255},
+				{R: 132, G: 130, B: 132, A: 255},
 				{R: 8, G: 4, B: 8, A: 255},
-				{R: 88, G: 87, B: 88, A: 255},
-				{R: 48, G: 45, B: 48, A: 255},
+				{R: 91, G: 88, B: 91, A: 255},
+				{R: 49, G: 46, B: 49, A: 255},
 			},
 		},
 	}
@@ -44,3 +45,12 @@ func TestInterpolateColors(t *testing.T) {
 		})
 	}
 }
+
+func TestExpand(t *testing.T) {
+	for v := 0; v < 32; v++ {
+		assert.Equal(t, byte(math.Round(float64(v)*255/31)), Expand5(byte(v)), "5 bit %d", v)
+	}
+	for v := 0; v < 64; v++ {
+		assert.Equal(t, byte(math.Round(float64(v)*255/63)), Expand6(byte(v)), "6 bit %d", v)
+	}
+}
@@ -7,6 +7,8 @@ import (
 )
 
 type ColorDecoder struct {
+	Mode    AlphaMode // alpha mode of the format the color block belongs to
+	Rules   Rules     // rules for the palette interpolation
 	colors  [4]color.NRGBA
 	indices []byte
 }
@@ -15,11 +17,20 @@ func (*ColorDecoder) New(bounds image.Rectangle) draw.Image {
 	return image.NewNRGBA(bounds)
 }
 
+// BlockColor decodes the palette and indices of a color block. Only DXT1 blocks (AlphaNone) switch to the three
+// color mode if c0 <= c1, the color blocks of DXT2 to DXT5 always use four colors.
 func (cd *ColorDecoder) BlockColor(colorsBlock []byte) {
-	cd.colors = InterpolateColors(colorsBlock[0:2:2], colorsBlock[2:4:4])
+	c0 := uint16(colorsBlock[0]) | uint16(colorsBlock[1])<<8
+	c1 := uint16(colorsBlock[2]) | uint16(colorsBlock[3])<<8
+	cd.colors = cd.Rules.Palette(c0, c1, c0 > c1 || cd.Mode != AlphaNone)
 	cd.indices = colorsBlock[4:8:8]
 }
 
+// Colors returns the palette of the last decoded color block.
+func (cd *ColorDecoder) Colors() [4]color.NRGBA {
+	return cd.colors
+}
+
 func (cd *ColorDecoder) PixelColor(pixelIndex byte) color.NRGBA {
 	colorIndex := ExtractIndex(cd.indices, pixelIndex, 2)
 	return cd.colors[colorIndex]
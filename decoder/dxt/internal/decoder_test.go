@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorDecoder_BlockColor(t *testing.T) {
+	// c0 < c1, which selects the three color mode for DXT1 only
+	block := []byte{0x00, 0x00, 0xFF, 0xFF, 0xE4, 0xE4, 0xE4, 0xE4}
+
+	var tests = map[AlphaMode][4]color.NRGBA{
+		AlphaNone:         {{A: 255}, {255, 255, 255, 255}, {128, 128, 128, 255}, {}},
+		AlphaExplicit:     {{A: 255}, {255, 255, 255, 255}, {85, 85, 85, 255}, {170, 170, 170, 255}},
+		AlphaInterpolated: {{A: 255}, {255, 255, 255, 255}, {85, 85, 85, 255}, {170, 170, 170, 255}},
+	}
+
+	for mode, expected := range tests {
+		cd := ColorDecoder{Mode: mode}
+		cd.BlockColor(block)
+		for i := byte(0); i < 4; i++ {
+			assert.Equal(t, expected[i], cd.PixelColor(i), "mode %d pixel %d", mode, i)
+		}
+	}
+}
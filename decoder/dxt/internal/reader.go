@@ -1,8 +1,6 @@
 package internal
 
 import (
-	"bufio"
-	"fmt"
 	"io"
 )
 
@@ -10,21 +8,66 @@ type Reader struct {
 	size   int
 	buffer []byte
 	rd     io.Reader
+	count  int64
 }
 
 func NewReader(r io.Reader, size byte) *Reader {
 	return &Reader{
 		size:   int(size),
-		buffer: make([]byte, size, size),
-		rd:     bufio.NewReaderSize(r, int(size)*256),
+		buffer: make([]byte, int(size)*BatchSize),
+		rd:     r,
 	}
 }
 
+// Reset switches the reader to r, keeping its buffer. The reader does not read ahead, so nothing beyond the
+// requested blocks is consumed from r.
+func (r *Reader) Reset(rd io.Reader) {
+	r.rd = rd
+	r.count = 0
+}
+
+// Count returns the number of bytes read since the last Reset.
+func (r *Reader) Count() int64 {
+	return r.count
+}
+
+// Skip skips the next n bytes, seeking if the underlying reader is an io.Seeker. Skipped bytes are included in
+// Count, even if seeking moved beyond the end of the stream, which is only detected by the next read.
+func (r *Reader) Skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if s, ok := r.rd.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekCurrent)
+		if err == nil {
+			r.count += n
+		}
+		return err
+	}
+	skipped, err := io.CopyN(io.Discard, r.rd, n)
+	r.count += skipped
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Read reads a single block, see ReadBlocks.
 func (r *Reader) Read() ([]byte, error) {
-	if n, err := r.rd.Read(r.buffer); err != nil {
-		return nil, err // including unexpected io.EOF
-	} else if n != r.size {
-		return nil, fmt.Errorf("corrupted block: size %d unexpected", n)
+	return r.ReadBlocks(1)
+}
+
+// ReadBlocks reads n consecutive blocks, where n must not exceed BatchSize. Short reads are retried until all
+// blocks are complete. As the blocks are expected to be there, io.ErrUnexpectedEOF is returned if the stream
+// ends early, even if it ends right before the blocks.
+func (r *Reader) ReadBlocks(n int) ([]byte, error) {
+	buf := r.buffer[:n*r.size]
+	read, err := io.ReadFull(r.rd, buf)
+	r.count += int64(read)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
 	}
-	return r.buffer, nil
+	return buf, nil
 }
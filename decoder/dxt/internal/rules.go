@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"image/color"
+)
+
+// Rules selects how the endpoints of a color block are expanded to 8 bit and how the palette is interpolated.
+// Hardware vendors deviate slightly from the D3D10 specification, the NVIDIA and AMD rules follow the bit-exact
+// decoders reverse engineered by Fabian Giesen and Rich Geldreich (rgbcx).
+type Rules byte
+
+// supported palette rules
+const (
+	D3D10  Rules = iota // exact expansion round(v*255/31), interpolation rounded to nearest
+	NVIDIA              // bit replication, red and blue interpolated on the 5 bit values, approximated green
+	AMD                 // bit replication, interpolation with 43/64 and 21/64 weights, rounded
+	D3D9                // bit replication, interpolation truncated as done by the legacy D3DX decoder
+)
+
+// Palette returns the four colors of a color block with the 565 endpoints c0 and c1. In the three color mode
+// (four is false) the last color is transparent black.
+func (r Rules) Palette(c0, c1 uint16, four bool) (cv [4]color.NRGBA) {
+	r0, g0, b0 := split565(c0)
+	r1, g1, b1 := split565(c1)
+
+	cv[0] = color.NRGBA{R: r.expand5(r0), G: r.expand6(g0), B: r.expand5(b0), A: 255}
+	cv[1] = color.NRGBA{R: r.expand5(r1), G: r.expand6(g1), B: r.expand5(b1), A: 255}
+	if four {
+		cv[2] = color.NRGBA{R: r.third5(r0, r1), G: r.third6(g0, g1), B: r.third5(b0, b1), A: 255}
+		cv[3] = color.NRGBA{R: r.third5(r1, r0), G: r.third6(g1, g0), B: r.third5(b1, b0), A: 255}
+	} else {
+		cv[2] = color.NRGBA{R: r.half5(r0, r1), G: r.half6(g0, g1), B: r.half5(b0, b1), A: 255}
+		cv[3] = color.NRGBA{} // A: 0; important and implicit
+	}
+	return cv
+}
+
+// split565 returns the raw channels of a 565 color.
+func split565(c uint16) (r, g, b byte) {
+	return byte(c >> 11), byte(c >> 5 & 0x3F), byte(c & 0x1F)
+}
+
+func (r Rules) expand5(v byte) byte {
+	if r == D3D10 {
+		return Expand5(v)
+	}
+	return v<<3 | v>>2
+}
+
+func (r Rules) expand6(v byte) byte {
+	if r == D3D10 {
+		return Expand6(v)
+	}
+	return v<<2 | v>>4
+}
+
+// third5 interpolates the 5 bit channel values v0 and v1 with the weights 2/3 and 1/3.
+func (r Rules) third5(v0, v1 byte) byte {
+	if r == NVIDIA {
+		return byte((2*int(v0) + int(v1)) * 22 / 8)
+	}
+	return r.third(int(r.expand5(v0)), int(r.expand5(v1)))
+}
+
+// third6 interpolates the 6 bit channel values v0 and v1 with the weights 2/3 and 1/3.
+func (r Rules) third6(v0, v1 byte) byte {
+	e0, e1 := int(r.expand6(v0)), int(r.expand6(v1))
+	if r == NVIDIA {
+		d := e1 - e0
+		return byte((256*e0 + d/4 + 128 + d*80) / 256)
+	}
+	return r.third(e0, e1)
+}
+
+// third interpolates the expanded values e0 and e1 with the weights 2/3 and 1/3.
+func (r Rules) third(e0, e1 int) byte {
+	switch r {
+	case AMD:
+		return byte((43*e0 + 21*e1 + 32) >> 6)
+	case D3D9:
+		return byte((2*e0 + e1) / 3)
+	default:
+		return byte((2*e0 + e1 + 1) / 3)
+	}
+}
+
+// half5 interpolates the 5 bit channel values v0 and v1 with the weights 1/2 and 1/2.
+func (r Rules) half5(v0, v1 byte) byte {
+	if r == NVIDIA {
+		return byte((int(v0) + int(v1)) * 33 / 8)
+	}
+	return r.half(int(r.expand5(v0)), int(r.expand5(v1)))
+}
+
+// half6 interpolates the 6 bit channel values v0 and v1 with the weights 1/2 and 1/2.
+func (r Rules) half6(v0, v1 byte) byte {
+	e0, e1 := int(r.expand6(v0)), int(r.expand6(v1))
+	if r == NVIDIA {
+		d := e1 - e0
+		return byte((256*e0 + d/4 + 128 + d*128) / 256)
+	}
+	return r.half(e0, e1)
+}
+
+// half interpolates the expanded values e0 and e1 with the weights 1/2 and 1/2.
+func (r Rules) half(e0, e1 int) byte {
+	if r == D3D9 {
+		return byte((e0 + e1) / 2)
+	}
+	return byte((e0 + e1 + 1) / 2)
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Palette(t *testing.T) {
+	type palette = [4]color.NRGBA
+
+	var tests = map[string]struct {
+		c0, c1 uint16
+		out    map[Rules]palette
+	}{
+		"white to black": {
+			c0: 0xFFFF, c1: 0x0000,
+			out: map[Rules]palette{
+				D3D10:  {{255, 255, 255, 255}, {0, 0, 0, 255}, {170, 170, 170, 255}, {85, 85, 85, 255}},
+				NVIDIA: {{255, 255, 255, 255}, {0, 0, 0, 255}, {170, 175, 170, 255}, {85, 80, 85, 255}},
+				AMD:    {{255, 255, 255, 255}, {0, 0, 0, 255}, {171, 171, 171, 255}, {84, 84, 84, 255}},
+				D3D9:   {{255, 255, 255, 255}, {0, 0, 0, 255}, {170, 170, 170, 255}, {85, 85, 85, 255}},
+			},
+		},
+		"dark gray to black": {
+			c0: 0x18E3, c1: 0x0000,
+			out: map[Rules]palette{
+				D3D10:  {{25, 28, 25, 255}, {0, 0, 0, 255}, {17, 19, 17, 255}, {8, 9, 8, 255}},
+				NVIDIA: {{24, 28, 24, 255}, {0, 0, 0, 255}, {16, 19, 16, 255}, {8, 9, 8, 255}},
+				AMD:    {{24, 28, 24, 255}, {0, 0, 0, 255}, {16, 19, 16, 255}, {8, 9, 8, 255}},
+				D3D9:   {{24, 28, 24, 255}, {0, 0, 0, 255}, {16, 18, 16, 255}, {8, 9, 8, 255}},
+			},
+		},
+		"three colors blue to red": {
+			c0: 0x001F, c1: 0xF800,
+			out: map[Rules]palette{
+				D3D10:  {{0, 0, 255, 255}, {255, 0, 0, 255}, {128, 0, 128, 255}, {}},
+				NVIDIA: {{0, 0, 255, 255}, {255, 0, 0, 255}, {127, 0, 127, 255}, {}},
+				AMD:    {{0, 0, 255, 255}, {255, 0, 0, 255}, {128, 0, 128, 255}, {}},
+				D3D9:   {{0, 0, 255, 255}, {255, 0, 0, 255}, {127, 0, 127, 255}, {}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for rules, expected := range test.out {
+				assert.Equal(t, expected, rules.Palette(test.c0, test.c1, test.c0 > test.c1), "rules %d", rules)
+
+				block := []byte{byte(test.c0), byte(test.c0 >> 8), byte(test.c1), byte(test.c1 >> 8), 0xE4, 0xE4, 0xE4, 0xE4}
+				b := NewBatch(AlphaNone, rules)
+				assert.Equal(t, 1, b.Decode(block))
+				assert.Equal(t, expected, palette(b.Pixels[0][0:4]), "batch rules %d", rules)
+			}
+		})
+	}
+}
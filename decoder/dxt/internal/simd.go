@@ -1,53 +1,148 @@
 package internal
 
 import (
+	"sync"
+
 	"github.com/pehringer/simd"
 )
 
-// WeightedSIMD applies the weighted average to two byte slices using SIMD.
+// BatchSize is the number of blocks decoded by a single Batch and therefore the lane length of every SIMD operation.
+const BatchSize = 256
+
+// The interpolated palette entries of all blocks of a batch are stored in vectors of consecutive lanes, one value
+// per block each, so that a single SIMD operation covers all entries and channels. Every call into the SIMD
+// package has a fixed cost that outweighs the arithmetic on a few hundred values, so fewer and longer operations
+// are considerably faster than one operation per entry and channel.
+const (
+	colorLanes = 9  // the entries c2, c3 and the half color of the three color mode, each for red, green and blue
+	alphaLanes = 10 // the six entries of the eight alpha mode followed by the four of the six alpha mode
+)
+
+// colorVector holds one float per block for every color lane, see colorLane.
+type colorVector [colorLanes * BatchSize]float32
+
+// endpointVector holds the color endpoints v0, v1, v0, v1 of every block, each for red, green and blue, whose first
+// nine lanes are the first and whose last nine lanes are the second operand of the color kernel: c2 interpolates
+// from v0 to v1, c3 from v1 to v0 and the half color from v0 to v1.
+type endpointVector [(colorLanes + 3) * BatchSize]float32
+
+// alphaVector holds one float per block for every alpha lane, see alphaLane.
+type alphaVector [alphaLanes * BatchSize]float32
+
+// colorLane returns the index of block i in a colorVector for the palette entry e (0 is c2, 1 is c3 and 2 the half
+// color) and the channel c.
+func colorLane(e, c, i int) int {
+	return (e*3+c)*BatchSize + i
+}
+
+// alphaLane returns the index of block i in an alphaVector for the interpolated alpha entry k, where 0 to 5 are
+// the entries of the eight alpha mode and 6 to 9 those of the six alpha mode.
+func alphaLane(k, i int) int {
+	return k*BatchSize + i
+}
 
-func WeightedSIMD(w0, w1 float32, v0, v1, out []float32) int {
-	// out[i] = (w0*v0[i] + w1*v1[i]) / (w0 + w1)
-	n := len(v0)
-	if n > len(v1) {
-		n = len(v1)
+// kernel describes the interpolation out[i] = x[i]*w0[i] + y[i]*w1[i] + q[i]*wq[i] + bias[i] of a vector, where the
+// result is truncated to an integer. All Rules can be expressed this way with weights that are either exact or
+// far enough from rounding boundaries. The weights hold one value per lane, so that every palette entry and
+// channel can use its own.
+type kernel struct {
+	w0, w1, wq, bias []float32 // wq is nil if the rules have no correction term
+}
+
+// apply computes the kernel for all lanes using SIMD. The tmp vector is used as scratch space.
+func (k *kernel) apply(out, x, y, q, tmp []float32) {
+	simd.MulFloat32(x, k.w0, out)
+	simd.MulFloat32(y, k.w1, tmp)
+	simd.AddFloat32(out, tmp, out)
+	if k.wq != nil {
+		simd.MulFloat32(q, k.wq, tmp)
+		simd.AddFloat32(out, tmp, out)
 	}
-	if n > len(out) {
-		n = len(out)
+	simd.AddFloat32(out, k.bias, out)
+}
+
+// weight holds the scalar weights of a single palette entry, see kernel.
+type weight struct {
+	w0, w1, wq, bias float32
+}
+
+// fill sets the weights of the lane with the index l, counted in lanes, to w.
+func (k *kernel) fill(l int, w weight) {
+	for i := l * BatchSize; i < (l+1)*BatchSize; i++ {
+		k.w0[i], k.w1[i], k.bias[i] = w.w0, w.w1, w.bias
+		if k.wq != nil {
+			k.wq[i] = w.wq
+		}
 	}
-	if n == 0 {
-		return 0
+}
+
+// newKernel returns a kernel for the given number of lanes. The correction term is only used if q is set.
+func newKernel(lanes int, q bool) *kernel {
+	n := lanes * BatchSize
+	k := &kernel{w0: make([]float32, n), w1: make([]float32, n), bias: make([]float32, n)}
+	if q {
+		k.wq = make([]float32, n)
 	}
-	w0s := make([]float32, n)
-	w1s := make([]float32, n)
-	for i := range w0s {
-		w0s[i] = w0
-		w1s[i] = w1
+	return k
+}
+
+// colorKernels hold the kernels interpolating the color entries per Rules, each is created on first use.
+var colorKernels = [4]func() *kernel{
+	D3D10:  sync.OnceValue(func() *kernel { return newColorKernel(D3D10) }),
+	NVIDIA: sync.OnceValue(func() *kernel { return newColorKernel(NVIDIA) }),
+	AMD:    sync.OnceValue(func() *kernel { return newColorKernel(AMD) }),
+	D3D9:   sync.OnceValue(func() *kernel { return newColorKernel(D3D9) }),
+}
+
+// newColorKernel builds the kernel of the rules r, whose third entries c2 and c3 interpolate with the weights 2/3
+// and 1/3 and whose half entry with 1/2 and 1/2. The operands of c3 are swapped by the batch, see Batch.gather.
+func newColorKernel(r Rules) *kernel {
+	var third, half [3]weight // per channel
+	switch r {
+	case D3D10: // round((2*e0 + e1) / 3) and round((e0 + e1) / 2)
+		third = all(weight{w0: 2. / 3, w1: 1. / 3, bias: .5})
+		half = all(weight{w0: .5, w1: .5, bias: .5})
+	case D3D9: // (2*e0 + e1) / 3 and (e0 + e1) / 2, the bias moves the result away from integers before truncation
+		third = all(weight{w0: 2. / 3, w1: 1. / 3, bias: 1. / 6})
+		half = all(weight{w0: .5, w1: .5, bias: .25})
+	case AMD: // (43*e0 + 21*e1 + 32) >> 6 and (e0 + e1 + 1) >> 1
+		third = all(weight{w0: 43. / 64, w1: 21. / 64, bias: .5})
+		half = all(weight{w0: .5, w1: .5, bias: .5})
+	case NVIDIA:
+		// (2*v0 + v1) * 22 / 8 and (v0 + v1) * 33 / 8 on the raw values of red and blue, and
+		// (256*e0 + d/4 + 128 + d*80) / 256 and (256*e0 + d/4 + 128 + d*128) / 256 for green with d = e1 - e0 and
+		// q = d/4
+		rb := weight{w0: 44. / 8, w1: 22. / 8}
+		third = [3]weight{rb, {w0: 176. / 256, w1: 80. / 256, wq: 1. / 256, bias: .5}, rb}
+		rb = weight{w0: 33. / 8, w1: 33. / 8}
+		half = [3]weight{rb, {w0: 128. / 256, w1: 128. / 256, wq: 1. / 256, bias: .5}, rb}
 	}
-	simd.MulFloat32(w0s, v0, out)
-	simd.MulFloat32(w1s, v1, w1s)
-	simd.AddFloat32(out, w1s, out)
-	denom := w0 + w1
-	for i := 0; i < n; i++ {
-		out[i] = out[i] / denom
+
+	k := newKernel(colorLanes, r == NVIDIA)
+	for c := 0; c < 3; c++ {
+		k.fill(c, third[c])   // c2
+		k.fill(3+c, third[c]) // c3
+		k.fill(6+c, half[c])
 	}
-	return n
+	return k
 }
 
-// ExtractIndexSIMD extracts indices from a byte slice using SIMD (for batch processing).
-// This is a stub for illustration; actual SIMD bit extraction is non-trivial and may need custom implementation.
-func ExtractIndexSIMD(bytes []byte, indices, lengths []byte, out []byte) int {
-	// Not implemented: SIMD bit extraction is complex and not directly supported by simd package.
-	// Fallback to scalar for now.
-	n := len(indices)
-	if n > len(lengths) {
-		n = len(lengths)
-	}
-	if n > len(out) {
-		n = len(out)
+// all returns the weight w for every channel.
+func all(w weight) [3]weight {
+	return [3]weight{w, w, w}
+}
+
+// alphaKernel returns the kernel interpolating the alpha entries, which is created on first use. The weights are
+// multiplied as fractions k/n, which is exact enough as none of the used divisors can produce a fraction close to
+// one half.
+var alphaKernel = sync.OnceValue(func() *kernel {
+	k := newKernel(alphaLanes, false)
+	fraction := func(w, n int) float32 { return float32(w) / float32(n) }
+	for j := 0; j < 6; j++ {
+		k.fill(j, weight{w0: fraction(6-j, 7), w1: fraction(j+1, 7), bias: .5})
 	}
-	for i := 0; i < n; i++ {
-		out[i] = ExtractIndex(bytes, indices[i], lengths[i])
+	for j := 0; j < 4; j++ {
+		k.fill(6+j, weight{w0: fraction(4-j, 5), w1: fraction(j+1, 5), bias: .5})
 	}
-	return n
-}
+	return k
+})
@@ -2,34 +2,29 @@ package internal
 
 import (
 	"testing"
-
-	"github.com/stretchr/testify/assert"
 )
 
-func TestWeightedSIMD(t *testing.T) {
-	w0, w1 := float32(2), float32(1)
-	v0 := []float32{10, 20, 30, 40}
-	v1 := []float32{1, 2, 3, 4}
-	out := make([]float32, 4)
-	WeightedSIMD(w0, w1, v0, v1, out)
-	// out[i] = (w0*v0[i] + w1*v1[i]) / (w0 + w1)
-	expected := []float32{
-		(2*10 + 1*1) / 3,
-		(2*20 + 1*2) / 3,
-		(2*30 + 1*3) / 3,
-		(2*40 + 1*4) / 3,
-	}
-	for i := range out {
-		assert.InDelta(t, expected[i], out[i], 0.01)
-	}
-}
+func TestAlphaKernelExhaustive(t *testing.T) {
+	var x, y, out, tmp alphaVector
+	for a := 0; a < 256; a++ {
+		for k := 0; k < alphaLanes; k++ {
+			for b := 0; b < BatchSize; b++ {
+				x[alphaLane(k, b)], y[alphaLane(k, b)] = float32(a), float32(b)
+			}
+		}
+		alphaKernel().apply(out[:], x[:], y[:], nil, tmp[:])
 
-func TestExtractIndexSIMD(t *testing.T) {
-	in := []byte{0b00100001, 0b10000100}
-	indices := []byte{0, 1, 2, 3}
-	lengths := []byte{4, 4, 4, 4}
-	out := make([]byte, 4)
-	ExtractIndexSIMD(in, indices, lengths, out)
-	expected := []byte{0b0001, 0b0010, 0b0100, 0b1000}
-	assert.Equal(t, expected, out)
+		for k := 0; k < alphaLanes; k++ {
+			w0, w1 := 6-k, k+1 // eight alpha mode
+			if k >= 6 {
+				w0, w1 = 4-(k-6), k-5 // six alpha mode
+			}
+			for b := 0; b < BatchSize; b++ {
+				expected := Weighted(float64(w0), byte(a), float64(w1), byte(b))
+				if got := byte(out[alphaLane(k, b)]); got != expected {
+					t.Fatalf("lane %d: interpolating %d and %d = %d, expected %d", k, a, b, got, expected)
+				}
+			}
+		}
+	}
 }
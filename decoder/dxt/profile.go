@@ -0,0 +1,36 @@
+package dxt
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/funatsufumiya/dds-simd/decoder/dxt/internal"
+)
+
+// ErrProfile is wrapped by the errors about profiles that are not one of the supported ones.
+var ErrProfile = errors.New("unknown profile")
+
+// Profile selects how the palettes of the color blocks are expanded and interpolated. GPU vendors deviate
+// slightly from the D3D10 specification, so the decoded pixels only match a screenshot taken on a specific
+// GPU when using its profile.
+type Profile byte
+
+// supported profiles
+const (
+	ProfileD3D10  = Profile(D3D10)  // exact expansion and rounding of the D3D10 specification, the default
+	ProfileNVIDIA = Profile(NVIDIA) // bit-exact decoding of NVIDIA GPUs
+	ProfileAMD    = Profile(AMD)    // bit-exact decoding of AMD GPUs
+	ProfileD3D9   = Profile(D3D9)   // truncating interpolation of the legacy D3D9 reference decoder
+)
+
+// selectProfile returns the optional profile, ProfileD3D10 if it is omitted, or an error wrapping ErrProfile if
+// it is not supported.
+func selectProfile(profile []Profile) (Profile, error) {
+	if len(profile) == 0 {
+		return ProfileD3D10, nil
+	}
+	if p := profile[0]; p > ProfileD3D9 {
+		return 0, fmt.Errorf("%w: %d", ErrProfile, p)
+	}
+	return profile[0], nil
+}
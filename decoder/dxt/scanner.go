@@ -0,0 +1,131 @@
+package dxt
+
+import (
+	"image/color"
+	"io"
+
+	. "github.com/funatsufumiya/dds-simd/decoder/dxt/internal"
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// Block is a compressed block of a texture as returned by a Scanner. Besides the raw bytes it holds the decoded
+// palettes and indices, which describe how the 4x4 pixels of the block are derived.
+type Block struct {
+	X, Y int    // position of the block in blocks, its top left pixel is at (4X, 4Y)
+	Raw  []byte // the bytes of the block, only valid until the next call of Scanner.Scan
+
+	ColorEndpoints [2]uint16      // the 565 color endpoints
+	Colors         [4]color.NRGBA // the color palette, interpolated with the profile of the scanner
+	ColorIndices   [16]byte       // index into Colors per pixel in row-major order
+
+	Alphas       [8]byte  // DXT5: the alpha palette, starting with the two alpha endpoints
+	AlphaIndices [16]byte // DXT5: index into Alphas per pixel; DXT3: the explicit 4 bit alpha per pixel
+
+	mode AlphaMode
+}
+
+// Pixel returns the decoded color of the pixel i of the block in row-major order.
+func (b *Block) Pixel(i int) color.NRGBA {
+	c := b.Colors[b.ColorIndices[i]]
+	switch b.mode {
+	case AlphaExplicit:
+		c.A = b.AlphaIndices[i] * 17
+	case AlphaInterpolated:
+		c.A = b.Alphas[b.AlphaIndices[i]]
+	}
+	return c
+}
+
+// Scanner iterates the blocks of a compressed texture in row-major order without decoding an image, for tooling
+// like block statistics or re-packing. The reader needs to be positioned at the start of the blocks, e.g. after
+// reading the header with header.Read:
+//
+//	s, err := dxt.NewScanner(r, h.FourCCString, int(h.Width), int(h.Height))
+//	for s.Scan() {
+//		b := s.Block()
+//		...
+//	}
+//	err = s.Err()
+type Scanner struct {
+	reader *Reader
+	decode strategy
+
+	columns, blocks int    // blocks per row and in total
+	next            int    // index of the next block
+	buffer          []byte // blocks read but not scanned yet
+	block           Block
+	err             error
+}
+
+// NewScanner creates a scanner for the blocks of a texture of the given DXT format and size read from r. The
+// optional profile selects how the color palettes are interpolated, ProfileD3D10 is used if it is omitted.
+func NewScanner(r io.Reader, fourCC string, width, height int, profile ...Profile) (*Scanner, error) {
+	d, err := New(fourCC, width, height, profile...)
+	if err != nil {
+		return nil, err
+	}
+	columns := (width + 3) / 4
+	s := &Scanner{
+		reader:  d.reader,
+		decode:  d.strategy,
+		columns: columns,
+		blocks:  columns * ((height + 3) / 4),
+	}
+	s.block.mode = d.batch.Mode()
+	s.reader.Reset(r)
+	return s, nil
+}
+
+// Scan advances to the next block, which is then available through Block. It returns false when all blocks have
+// been scanned or an error occurred, which is returned by Err.
+func (s *Scanner) Scan() bool {
+	if s.err != nil || s.next == s.blocks {
+		return false
+	}
+
+	size := int(s.decode.BlockSize())
+	if len(s.buffer) == 0 {
+		s.buffer, s.err = s.reader.ReadBlocks(min(BatchSize, s.blocks-s.next))
+		if s.err == io.ErrUnexpectedEOF {
+			s.err = &header.TruncatedError{
+				Section:  "blocks",
+				Expected: int64(s.blocks * size),
+				Received: s.reader.Count(),
+			}
+		}
+		if s.err != nil {
+			return false
+		}
+	}
+
+	b := &s.block
+	b.X, b.Y = s.next%s.columns, s.next/s.columns
+	b.Raw, s.buffer = s.buffer[:size:size], s.buffer[size:]
+	s.next++
+
+	colors := b.Raw[size-8:]
+	b.ColorEndpoints[0] = uint16(colors[0]) | uint16(colors[1])<<8
+	b.ColorEndpoints[1] = uint16(colors[2]) | uint16(colors[3])<<8
+	s.decode.DecodeBlock(b.Raw)
+	b.Colors = s.decode.Colors()
+	SpreadIndices(&b.ColorIndices, colors[4:], 2)
+	switch d := s.decode.(type) {
+	case *dxt3:
+		SpreadIndices(&b.AlphaIndices, b.Raw[0:8], 4)
+	case *dxt5:
+		b.Alphas = d.alphaValues
+		SpreadIndices(&b.AlphaIndices, b.Raw[2:8], 3)
+	}
+	return true
+}
+
+// Block returns the block of the last successful call of Scan. It is overwritten by the next call.
+func (s *Scanner) Block() *Block {
+	return &s.block
+}
+
+// Err returns the error that stopped the scanning, or nil if all blocks have been scanned. A stream ending early
+// produces a *header.TruncatedError with an offset relative to the first block.
+func (s *Scanner) Err() error {
+	return s.err
+}
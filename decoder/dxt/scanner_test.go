@@ -0,0 +1,77 @@
+package dxt
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanner(t *testing.T) {
+	for _, fourCC := range []string{"DXT1", "DXT3", "DXT5"} {
+		t.Run(fourCC, func(t *testing.T) {
+			// more blocks than fit into a batch
+			width, height := 1030, 9
+			data := randomTexture(fourCC, width, height)
+			d, err := New(fourCC, width, height, ProfileNVIDIA)
+			assert.NoError(t, err)
+			expected, err := d.Decode(bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			s, err := NewScanner(bytes.NewReader(data), fourCC, width, height, ProfileNVIDIA)
+			assert.NoError(t, err)
+			img := image.NewNRGBA(image.Rect(0, 0, width, height))
+			var count int
+			for s.Scan() {
+				b := s.Block()
+				assert.Equal(t, image.Pt(count%258, count/258), image.Pt(b.X, b.Y))
+				assert.Equal(t, data[count*len(b.Raw):(count+1)*len(b.Raw)], b.Raw)
+				for i := 0; i < 16; i++ {
+					img.SetNRGBA(b.X*4+i%4, b.Y*4+i/4, b.Pixel(i))
+				}
+				count++
+			}
+			assert.NoError(t, s.Err())
+			assert.Equal(t, 258*3, count)
+			assert.Equal(t, expected, img)
+			assert.False(t, s.Scan())
+		})
+	}
+}
+
+func TestScanner_Block(t *testing.T) {
+	data := []byte{
+		0x12, 0x34, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // alpha 0x12 and 0x34, all indices 7
+		0x00, 0xF8, 0x1F, 0x00, 0xE4, 0xE4, 0xE4, 0xE4, // red and blue, indices 0 1 2 3 per row
+	}
+	s, err := NewScanner(bytes.NewReader(data), "DXT5", 4, 4)
+	assert.NoError(t, err)
+	assert.True(t, s.Scan())
+
+	b := s.Block()
+	assert.Equal(t, [2]uint16{0xF800, 0x001F}, b.ColorEndpoints)
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, b.Colors[0])
+	assert.Equal(t, [16]byte{0, 1, 2, 3, 0, 1, 2, 3, 0, 1, 2, 3, 0, 1, 2, 3}, b.ColorIndices)
+	assert.Equal(t, [8]byte{0x12, 0x34, 0x19, 0x20, 0x26, 0x2D, 0, 255}, b.Alphas)
+	assert.Equal(t, byte(7), b.AlphaIndices[15])
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, b.Pixel(1))
+	assert.False(t, s.Scan())
+}
+
+func TestScanner_Truncated(t *testing.T) {
+	data := randomTexture("DXT1", 8, 8)
+	s, err := NewScanner(bytes.NewReader(data[:20]), "DXT1", 8, 8)
+	assert.NoError(t, err)
+	for s.Scan() {
+	}
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, s.Err(), &truncated) {
+		assert.Equal(t, header.TruncatedError{Section: "blocks", Expected: 32, Received: 20}, *truncated)
+	}
+
+	_, err = NewScanner(bytes.NewReader(data), "ATI2", 8, 8)
+	assert.ErrorIs(t, err, header.ErrUnsupported)
+}
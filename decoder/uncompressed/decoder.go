@@ -2,57 +2,299 @@ package uncompressed
 
 import (
 	"image"
+	"image/color"
+	"image/draw"
 	"io"
 
+	"github.com/funatsufumiya/dds-simd/hdr"
 	"github.com/funatsufumiya/dds-simd/header"
 )
 
-type Decoder struct {
-	flags  header.Flags[header.DDPFf]
-	bounds image.Point
+type (
+	Decoder struct {
+		// ToneMap converts float textures to 8 bit. If it is hdr.ToneMapNone, they are decoded into an *hdr.Image.
+		ToneMap hdr.ToneMap
+		// Signed selects how signed normalized channels are mapped to the unsigned channels of the image. It
+		// needs to be set before Reset.
+		Signed SignedMapping
+
+		format
+		pitch  int // bytes per row including the padding
+		bounds image.Point
+		buffer []byte
+	}
+
+	// format converts the rows of a pixel format into an image
+	format interface {
+		PixelSize() int                        // bytes per pixel
+		ColorModel() color.Model               // color model of the created images
+		New(bounds image.Rectangle) draw.Image // creates the image to decode into
+		Row(dst draw.Image, y int, src []byte) // converts the pixels of the row y counted from the top of dst
+	}
+)
+
+// finders look up the supported formats, each returns nil for textures in another format
+var finders = []func(h *header.Header, m SignedMapping) format{findRGB, findFloat, findInteger, findPacked, findSigned, findPalette}
+
+// find returns the format of the texture described by h or nil if it is not supported. Formats with signed
+// channels use the mapping m.
+func find(h *header.Header, m SignedMapping) format {
+	for _, find := range finders {
+		if f := find(h, m); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// Supported returns if textures described by h can be decoded.
+func Supported(h *header.Header) bool {
+	return find(h, SignedBias) != nil
+}
+
+// ColorModel returns the color model of the image decoded from textures described by h with the given tone map,
+// or nil if they are not supported.
+func ColorModel(h *header.Header, toneMap hdr.ToneMap) color.Model {
+	f := find(h, SignedBias)
+	if f == nil {
+		return nil
+	} else if m := f.ColorModel(); m == hdr.ColorModel && toneMap != hdr.ToneMapNone {
+		return color.NRGBAModel
+	} else {
+		return m
+	}
 }
 
 func New(header *header.Header) *Decoder {
-	return &Decoder{
-		flags:  header.PixelFlags,
-		bounds: image.Pt(int(header.Width), int(header.Height)),
+	d := new(Decoder)
+	d.Reset(header)
+	return d
+}
+
+// Reset prepares the decoder for another texture, as if it was created with New. The row buffer is kept.
+func (d *Decoder) Reset(header *header.Header) {
+	d.format = find(header, d.Signed)
+	d.bounds = image.Pt(int(header.Width), int(header.Height))
+	d.pitch = 0
+	if pitch, err := header.Pitch(); err == nil {
+		d.pitch = int(pitch)
 	}
 }
 
+// Decode reads the rows of the texture according to the pitch, which honors the padding declared in the header.
 func (d *Decoder) Decode(r io.Reader) (image.Image, error) {
-	rgba := image.NewNRGBA(image.Rectangle{Max: d.bounds})
-	if rgba.Rect.Empty() {
-		return rgba, nil
-	}
-
-	switch d.flags.F {
-	case header.DDPFAlphaPixels | header.DDPFRGB:
-		for y := 0; y < d.bounds.Y; y++ {
-			p := rgba.Pix[y*rgba.Stride : y*rgba.Stride+d.bounds.X*4]
-			if _, err := io.ReadFull(r, p); err != nil {
-				return nil, err
-			}
-			// BGRA to RGBA re-order.
-			for i := 0; i < len(p); i += 4 {
-				p[i+0], p[i+2] = p[i+2], p[i+0]
-			}
-		}
-	case header.DDPFRGB:
-		b := make([]byte, 3*d.bounds.X)
-		for y := 0; y < d.bounds.Y; y++ {
-			if _, err := io.ReadFull(r, b); err != nil {
-				return nil, err
-			}
-			p := rgba.Pix[y*rgba.Stride : y*rgba.Stride+d.bounds.X*4]
-			// BGRA to RGBA re-order.
-			for i, j := 0, 0; i < len(p); i, j = i+4, j+3 {
-				p[i+0] = b[j+2]
-				p[i+1] = b[j+1]
-				p[i+2] = b[j+0]
-				p[i+3] = 0xFF
-			}
-		}
-	}
-
-	return rgba, nil
+	img := d.New(image.Rectangle{Max: d.bounds})
+	if d.bounds.X <= 0 || d.bounds.Y <= 0 {
+		return img, nil
+	}
+
+	if err := d.readRows(r, img, 0, d.bounds.Y); err != nil {
+		return nil, err
+	}
+	return d.toneMap(img), nil
+}
+
+// DecodeRegion decodes only the rows of the texture covering rect and returns the part of the texture within rect,
+// clipped to the bounds of the texture. The image keeps the coordinates of the texture like image.NRGBA.SubImage
+// does, but only holds the pixels of the region. The rows before the region are skipped, by seeking if r is an
+// io.Seeker, and the rows after it are not read.
+func (d *Decoder) DecodeRegion(r io.Reader, rect image.Rectangle) (image.Image, error) {
+	rect = rect.Intersect(image.Rectangle{Max: d.bounds})
+	img := d.New(rect)
+	if rect.Empty() {
+		return d.toneMap(img), nil
+	}
+
+	if err := d.skip(r, rect.Min.Y); err != nil {
+		return nil, err
+	}
+	size := d.PixelSize()
+	b := d.row(size * d.bounds.X)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		if err := d.readRow(r, b, y); err != nil {
+			return nil, err
+		}
+		d.Row(img, y-rect.Min.Y, b[rect.Min.X*size:rect.Max.X*size])
+	}
+	return d.toneMap(img), nil
+}
+
+// DecodeTo decodes the texture into dst at dst.Bounds().Min, see DecodeAt.
+func (d *Decoder) DecodeTo(r io.Reader, dst draw.Image) error {
+	return d.DecodeAt(r, dst, dst.Bounds().Min)
+}
+
+// DecodeAt decodes the texture into dst with its top left pixel at p, e.g. to decode textures straight into an
+// atlas. Float textures are tone mapped if a tone map is set. If dst does not cover the texture at p, a
+// *header.BoundsError is returned before anything is read. The rows are copied directly if dst has the type of the
+// images returned by Decode, otherwise they are converted with draw.Draw.
+func (d *Decoder) DecodeAt(r io.Reader, dst draw.Image, p image.Point) error {
+	if err := header.CheckBounds(dst.Bounds(), p, d.bounds.X, d.bounds.Y); err != nil {
+		return err
+	}
+	if d.bounds.X <= 0 || d.bounds.Y <= 0 {
+		return nil
+	}
+
+	line := d.New(image.Rect(0, 0, d.bounds.X, 1))
+	b := d.row(d.PixelSize() * d.bounds.X)
+	for y := 0; y < d.bounds.Y; y++ {
+		if err := d.readRow(r, b, y); err != nil {
+			return err
+		}
+		d.Row(line, 0, b)
+		d.put(dst, image.Pt(p.X, p.Y+y), line)
+	}
+	return nil
+}
+
+// put copies the decoded row line into dst with its first pixel at p.
+func (d *Decoder) put(dst draw.Image, p image.Point, line draw.Image) {
+	if f, ok := line.(*hdr.Image); ok && d.ToneMap != hdr.ToneMapNone {
+		for x := 0; x < d.bounds.X; x++ {
+			dst.Set(p.X+x, p.Y, d.ToneMap.NRGBA(f.FloatAt(x, 0)))
+		}
+		return
+	}
+
+	switch dst := dst.(type) {
+	case *image.NRGBA:
+		if src, ok := line.(*image.NRGBA); ok {
+			copy(dst.Pix[dst.PixOffset(p.X, p.Y):], src.Pix)
+			return
+		}
+	case *image.NRGBA64:
+		if src, ok := line.(*image.NRGBA64); ok {
+			copy(dst.Pix[dst.PixOffset(p.X, p.Y):], src.Pix)
+			return
+		}
+	case *hdr.Image:
+		if src, ok := line.(*hdr.Image); ok {
+			copy(dst.Pix[dst.PixOffset(p.X, p.Y):], src.Pix)
+			return
+		}
+	}
+	draw.Draw(dst, line.Bounds().Add(p), line, image.Point{}, draw.Src)
+}
+
+// DecodeBands reads the texture four rows at a time, like the compressed formats store their blocks, and passes each
+// band to fn from top to bottom, so that large textures can be processed without holding the whole image. The band
+// has the type of the images returned by Decode and the coordinates of the texture. It is reused for the next band
+// and must not be retained. An error returned by fn stops decoding and is returned.
+func (d *Decoder) DecodeBands(r io.Reader, fn func(band image.Image) error) error {
+	if d.bounds.X <= 0 || d.bounds.Y <= 0 {
+		return nil
+	}
+	band := d.New(image.Rect(0, 0, d.bounds.X, 4))
+	var mapped *image.NRGBA
+	if _, ok := band.(*hdr.Image); ok && d.ToneMap != hdr.ToneMapNone {
+		mapped = image.NewNRGBA(band.Bounds())
+	}
+
+	for y := 0; y < d.bounds.Y; y += 4 {
+		rows := image.Rect(0, y, d.bounds.X, min(y+4, d.bounds.Y))
+		move(band, rows)
+		if err := d.readRows(r, band, rows.Min.Y, rows.Max.Y); err != nil {
+			return err
+		}
+
+		var out image.Image = band
+		if mapped != nil {
+			move(mapped, rows)
+			d.ToneMap.Draw(mapped, band.(*hdr.Image))
+			out = mapped
+		}
+		if err := fn(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move changes the bounds of an image created by a format to r, which must not hold more pixels than it was created
+// with.
+func move(img draw.Image, r image.Rectangle) {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		img.Rect, img.Pix = r, img.Pix[:cap(img.Pix)][:r.Dy()*img.Stride]
+	case *image.NRGBA64:
+		img.Rect, img.Pix = r, img.Pix[:cap(img.Pix)][:r.Dy()*img.Stride]
+	case *image.Paletted:
+		img.Rect, img.Pix = r, img.Pix[:cap(img.Pix)][:r.Dy()*img.Stride]
+	case *hdr.Image:
+		img.Rect, img.Pix = r, img.Pix[:cap(img.Pix)][:r.Dy()*img.Stride]
+	}
+}
+
+// readRows reads the rows of the texture from first up to last and converts them into dst, whose top row is the
+// row first.
+func (d *Decoder) readRows(r io.Reader, dst draw.Image, first, last int) error {
+	b := d.row(d.PixelSize() * d.bounds.X)
+	for y := first; y < last; y++ {
+		if err := d.readRow(r, b, y); err != nil {
+			return err
+		}
+		d.Row(dst, y-first, b)
+	}
+	return nil
+}
+
+// toneMap converts img to 8 bit if it is an *hdr.Image and a tone map is set.
+func (d *Decoder) toneMap(img draw.Image) image.Image {
+	if f, ok := img.(*hdr.Image); ok && d.ToneMap != hdr.ToneMapNone {
+		return d.ToneMap.Image(f)
+	}
+	return img
+}
+
+// skip skips the first rows of the texture, seeking if r is an io.Seeker. A stream ending early produces a
+// *header.TruncatedError like readRow.
+func (d *Decoder) skip(r io.Reader, rows int) error {
+	pitch := max(d.pitch, d.PixelSize()*d.bounds.X)
+	n := int64(pitch * rows)
+	if n == 0 {
+		return nil
+	}
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekCurrent)
+		return err
+	}
+	skipped, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF {
+		return &header.TruncatedError{
+			Section:  "pixels",
+			Expected: int64(pitch * d.bounds.Y),
+			Received: skipped,
+		}
+	}
+	return err
+}
+
+// readRow reads the row y of the texture completely into p and skips the padding up to the pitch. If the stream
+// ends early, a *header.TruncatedError tells how many bytes of the surface were expected and received. Its offset
+// is relative to the surface.
+func (d *Decoder) readRow(r io.Reader, p []byte, y int) error {
+	pitch := max(d.pitch, len(p))
+	n, err := io.ReadFull(r, p)
+	if err == nil && pitch > len(p) {
+		var padding int64
+		padding, err = io.CopyN(io.Discard, r, int64(pitch-len(p)))
+		n += int(padding)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &header.TruncatedError{
+			Section:  "pixels",
+			Expected: int64(pitch * d.bounds.Y),
+			Received: int64(pitch*y + n),
+		}
+	}
+	return err
+}
+
+// row returns the row buffer with the given size, which only grows if necessary.
+func (d *Decoder) row(size int) []byte {
+	if cap(d.buffer) < size {
+		d.buffer = make([]byte, size)
+	}
+	return d.buffer[:size]
 }
@@ -0,0 +1,161 @@
+package uncompressed
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+	"testing/iotest"
+
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+// rgbHeader returns the header of an uncompressed texture of 2x2 pixels with the given bits and flags.
+func rgbHeader(bits uint32, flags header.DDPFf, pitch uint32) *header.Header {
+	h := &header.Header{
+		DDSHeader:  header.DDSHeader{Width: 2, Height: 2, PitchOrLinearSize: pitch},
+		DDPFHeader: header.DDPFHeader{PixelFlags: header.Flags[header.DDPFf]{F: flags}, RgbBitCount: bits},
+	}
+	h.TextureFlags.F = header.DDSDHeaderFlagsTexture
+	if pitch != 0 {
+		h.TextureFlags.F |= header.DDSDPitch
+	}
+	return h
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	var tests = map[string]struct {
+		header *header.Header
+		data   []byte
+		alpha  byte
+	}{
+		"BGR":         {rgbHeader(24, header.DDPFRGB, 0), []byte{3, 2, 1, 6, 5, 4, 9, 8, 7, 12, 11, 10}, 255},
+		"BGR padded":  {rgbHeader(24, header.DDPFRGB, 8), []byte{3, 2, 1, 6, 5, 4, 0, 0, 9, 8, 7, 12, 11, 10, 0, 0}, 255},
+		"BGRX":        {rgbHeader(32, header.DDPFRGB, 0), []byte{3, 2, 1, 0, 6, 5, 4, 0, 9, 8, 7, 0, 12, 11, 10, 0}, 255},
+		"BGRA":        {rgbHeader(32, header.DDPFRGB|header.DDPFAlphaPixels, 0), []byte{3, 2, 1, 9, 6, 5, 4, 9, 9, 8, 7, 9, 12, 11, 10, 9}, 9},
+		"BGRA padded": {rgbHeader(32, header.DDPFRGB|header.DDPFAlphaPixels, 12), []byte{3, 2, 1, 9, 6, 5, 4, 9, 0, 0, 0, 0, 9, 8, 7, 9, 12, 11, 10, 9, 0, 0, 0, 0}, 9},
+		"small pitch": {rgbHeader(24, header.DDPFRGB, 2), []byte{3, 2, 1, 6, 5, 4, 9, 8, 7, 12, 11, 10}, 255},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			img, err := New(test.header).Decode(bytes.NewReader(test.data))
+			assert.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 2, 2), img.Bounds())
+			for i, c := range []color.NRGBA{{1, 2, 3, 0}, {4, 5, 6, 0}, {7, 8, 9, 0}, {10, 11, 12, 0}} {
+				c.A = test.alpha
+				assert.Equal(t, c, img.At(i%2, i/2))
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeTruncated(t *testing.T) {
+	_, err := New(rgbHeader(24, header.DDPFRGB, 8)).Decode(bytes.NewReader(make([]byte, 11)))
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, header.TruncatedError{Section: "pixels", Expected: 16, Received: 11}, *truncated)
+	}
+}
+
+func TestDecoder_DecodeRegion(t *testing.T) {
+	h := rgbHeader(24, header.DDPFRGB, 8)
+	h.Height = 3
+	data := []byte{3, 2, 1, 6, 5, 4, 0, 0, 9, 8, 7, 12, 11, 10, 0, 0, 15, 14, 13, 18, 17, 16, 0, 0}
+	d := New(h)
+
+	r := bytes.NewReader(data)
+	img, err := d.DecodeRegion(r, image.Rect(1, 1, 5, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(1, 1, 2, 2), img.Bounds())
+	assert.Equal(t, color.NRGBA{10, 11, 12, 255}, img.At(1, 1))
+	assert.Equal(t, 8, r.Len(), "the rows after the region are not read")
+
+	img, err = d.DecodeRegion(iotest.OneByteReader(bytes.NewReader(data)), image.Rect(0, 2, 2, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 2, 2, 3), img.Bounds())
+	assert.Equal(t, color.NRGBA{13, 14, 15, 255}, img.At(0, 2))
+
+	_, err = d.DecodeRegion(iotest.OneByteReader(bytes.NewReader(data[:12])), image.Rect(0, 2, 2, 3))
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, header.TruncatedError{Section: "pixels", Expected: 24, Received: 12}, *truncated)
+	}
+
+	img, err = New(floatHeader(113, 0)).DecodeRegion(bytes.NewReader(make([]byte, 32)), image.Rect(1, 0, 2, 1))
+	assert.NoError(t, err)
+	assert.IsType(t, &hdr.Image{}, img)
+	assert.Equal(t, image.Rect(1, 0, 2, 1), img.Bounds())
+
+	// a column of a R32F texture only allocates its own pixels, not the full width of its rows
+	h = floatHeader(114, 0)
+	h.Width, h.Height = 64, 64
+	data = make([]byte, 64*64*4)
+	for y := 0; y < 64; y++ {
+		copy(data[(y*64+5)*4:], []byte{0x00, 0x00, 0x80, 0x3F}) // 1.0
+	}
+	img, err = New(h).DecodeRegion(bytes.NewReader(data), image.Rect(5, 8, 6, 64))
+	if assert.NoError(t, err) {
+		assert.Equal(t, hdr.Color{R: 1, A: 1}, img.At(5, 10))
+		assert.Len(t, img.(*hdr.Image).Pix, 56*4)
+	}
+}
+
+func TestDecoder_DecodeAt(t *testing.T) {
+	data := []byte{3, 2, 1, 6, 5, 4, 9, 8, 7, 12, 11, 10}
+	d := New(rgbHeader(24, header.DDPFRGB, 0))
+
+	atlas := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	assert.NoError(t, d.DecodeAt(bytes.NewReader(data), atlas, image.Pt(2, 1)))
+	assert.Equal(t, color.NRGBA{}, atlas.NRGBAAt(1, 1))
+	assert.Equal(t, color.NRGBA{1, 2, 3, 255}, atlas.NRGBAAt(2, 1))
+	assert.Equal(t, color.NRGBA{10, 11, 12, 255}, atlas.NRGBAAt(3, 2))
+
+	rgba := image.NewRGBA(image.Rect(-1, -1, 1, 1))
+	assert.NoError(t, d.DecodeTo(bytes.NewReader(data), rgba))
+	assert.Equal(t, color.RGBA{7, 8, 9, 255}, rgba.At(-1, 0))
+
+	err := d.DecodeAt(bytes.NewReader(data), atlas, image.Pt(3, 3))
+	assert.ErrorIs(t, err, header.ErrBounds)
+
+	f := New(floatHeader(116, 0)) // A32B32G32R32F
+	f.ToneMap = hdr.ToneMapClamp
+	floats := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	assert.NoError(t, f.DecodeTo(bytes.NewReader(make([]byte, 32)), floats))
+	assert.Equal(t, color.NRGBA{}, floats.NRGBAAt(1, 0))
+
+	f.ToneMap = hdr.ToneMapNone
+	img := hdr.NewImage(image.Rect(0, 0, 3, 1))
+	assert.NoError(t, f.DecodeAt(bytes.NewReader(make([]byte, 32)), img, image.Pt(1, 0)))
+}
+
+func TestDecoder_DecodeBands(t *testing.T) {
+	h := rgbHeader(24, header.DDPFRGB, 0)
+	h.Height = 5
+	data := make([]byte, 30)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	var bands []image.Rectangle
+	var last color.Color
+	err := New(h).DecodeBands(bytes.NewReader(data), func(band image.Image) error {
+		bands = append(bands, band.Bounds())
+		last = band.At(1, band.Bounds().Max.Y-1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []image.Rectangle{image.Rect(0, 0, 2, 4), image.Rect(0, 4, 2, 5)}, bands)
+	assert.Equal(t, color.NRGBA{29, 28, 27, 255}, last)
+
+	f := New(floatHeader(116, 0)) // A32B32G32R32F
+	f.ToneMap = hdr.ToneMapClamp
+	err = f.DecodeBands(bytes.NewReader(make([]byte, 32)), func(band image.Image) error {
+		assert.IsType(t, &image.NRGBA{}, band)
+		assert.Equal(t, image.Rect(0, 0, 2, 1), band.Bounds())
+		return nil
+	})
+	assert.NoError(t, err)
+}
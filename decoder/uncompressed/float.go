@@ -0,0 +1,108 @@
+package uncompressed
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// floats decodes pixels with 16 or 32 bit float channels in RGBA order into an *hdr.Image. Missing color channels
+// are zero and a missing alpha channel is one.
+type floats struct {
+	channels int  // number of stored channels
+	half     bool // 16 bit floats instead of 32 bit
+}
+
+// legacy D3DFMT codes of float formats, stored as FourCC
+var floatFourCCs = map[header.D3DFormat]floats{
+	header.D3DFMTR16F:          {1, true},
+	header.D3DFMTG16R16F:       {2, true},
+	header.D3DFMTA16B16G16R16F: {4, true},
+	header.D3DFMTR32F:          {1, false},
+	header.D3DFMTG32R32F:       {2, false},
+	header.D3DFMTA32B32G32R32F: {4, false},
+}
+
+// DXGI float formats
+var floatDXGI = map[uint32]floats{
+	2:  {4, false}, // DXGI_FORMAT_R32G32B32A32_FLOAT
+	6:  {3, false}, // DXGI_FORMAT_R32G32B32_FLOAT
+	10: {4, true},  // DXGI_FORMAT_R16G16B16A16_FLOAT
+	16: {2, false}, // DXGI_FORMAT_R32G32_FLOAT
+	34: {2, true},  // DXGI_FORMAT_R16G16_FLOAT
+	41: {1, false}, // DXGI_FORMAT_R32_FLOAT
+	54: {1, true},  // DXGI_FORMAT_R16_FLOAT
+}
+
+// findFloat returns the float format of the texture described by h or nil if it has none.
+func findFloat(h *header.Header, _ SignedMapping) format {
+	var f floats
+	var ok bool
+	if !h.PixelFlags.Has(header.DDPFFourCC) {
+		return nil
+	} else if h.FourCCString == header.FourCCDX10 {
+		f, ok = floatDXGI[h.DxgiFormat]
+	} else {
+		f, ok = floatFourCCs[header.D3DFormat(h.FourCC)]
+	}
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+func (f *floats) PixelSize() int {
+	if f.half {
+		return 2 * f.channels
+	}
+	return 4 * f.channels
+}
+
+func (*floats) ColorModel() color.Model { return hdr.ColorModel }
+
+func (*floats) New(bounds image.Rectangle) draw.Image { return hdr.NewImage(bounds) }
+
+func (f *floats) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*hdr.Image)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)/f.PixelSize()*4]
+	for i, j := 0, 0; i < len(p); i += 4 {
+		p[i+0], p[i+1], p[i+2], p[i+3] = 0, 0, 0, 1
+		for c := 0; c < f.channels; c++ {
+			if f.half {
+				p[i+c] = float16(binary.LittleEndian.Uint16(src[j:]))
+				j += 2
+			} else {
+				p[i+c] = math.Float32frombits(binary.LittleEndian.Uint32(src[j:]))
+				j += 4
+			}
+		}
+	}
+}
+
+// float16 converts the IEEE 754 half precision float v to a float32.
+func float16(v uint16) float32 {
+	f := minifloat(uint32(v&0x7FFF), 10)
+	if v&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+// minifloat converts the unsigned float v with a 5 bit exponent and the given bits of mantissa, as used by half
+// precision and the packed float formats, to a float32.
+func minifloat(v uint32, mantissaBits uint) float32 {
+	exp, mantissa := v>>mantissaBits, v&(1<<mantissaBits-1)
+	switch {
+	case exp == 0x1F: // infinity and NaN
+		return math.Float32frombits(0xFF<<23 | mantissa<<(23-mantissaBits))
+	case exp != 0: // normal
+		return math.Float32frombits((exp+127-15)<<23 | mantissa<<(23-mantissaBits))
+	default: // zero and subnormal
+		return float32(mantissa) / float32(uint32(1)<<mantissaBits) / (1 << 14)
+	}
+}
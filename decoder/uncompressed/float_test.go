@@ -0,0 +1,99 @@
+package uncompressed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat16(t *testing.T) {
+	var tests = map[uint16]float32{
+		0x0000: 0,
+		0x3C00: 1,
+		0xC000: -2,
+		0x3555: 0.333251953125,
+		0x7BFF: 65504,
+		0x0001: 1.0 / (1 << 24),
+		0x8001: -1.0 / (1 << 24),
+		0x7C00: float32(math.Inf(1)),
+		0xFC00: float32(math.Inf(-1)),
+	}
+	for v, f := range tests {
+		assert.Equal(t, f, float16(v), "%#04x", v)
+	}
+	assert.True(t, math.IsNaN(float64(float16(0x7E00))))
+}
+
+// floatHeader returns the header of a 2x1 texture with a legacy FourCC code or a DXGI format.
+func floatHeader(fourCC, dxgi uint32) *header.Header {
+	h := &header.Header{
+		DDSHeader:  header.DDSHeader{Width: 2, Height: 1},
+		DDPFHeader: header.DDPFHeader{PixelFlags: header.Flags[header.DDPFf]{F: header.DDPFFourCC}, FourCC: fourCC},
+	}
+	h.TextureFlags.F = header.DDSDHeaderFlagsTexture
+	h.FourCCString = string(binary.LittleEndian.AppendUint32(nil, fourCC))
+	if dxgi != 0 {
+		h.FourCCString, h.DxgiFormat = header.FourCCDX10, dxgi
+	}
+	return h
+}
+
+func TestDecoder_DecodeFloat(t *testing.T) {
+	half := func(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+	single := func(v ...float32) (b []byte) {
+		for _, f := range v {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+		}
+		return
+	}
+
+	var tests = map[string]struct {
+		header *header.Header
+		data   []byte
+		pixel  hdr.Color
+	}{
+		"R16F":          {floatHeader(111, 0), append(half(0x3C00), half(0x4000)...), hdr.Color{R: 2, A: 1}},
+		"G16R16F":       {floatHeader(112, 0), []byte{0, 0, 0, 0, 0, 0x3C, 0, 0xC0}, hdr.Color{R: 1, G: -2, A: 1}},
+		"A16B16G16R16F": {floatHeader(113, 0), make([]byte, 16), hdr.Color{}},
+		"R32F":          {floatHeader(114, 0), single(0, 5), hdr.Color{R: 5, A: 1}},
+		"G32R32F":       {floatHeader(115, 0), single(0, 0, 1.5, 2.5), hdr.Color{R: 1.5, G: 2.5, A: 1}},
+		"A32B32G32R32F": {floatHeader(116, 0), single(0, 0, 0, 0, 1, 2, 3, .5), hdr.Color{R: 1, G: 2, B: 3, A: .5}},
+		"R32G32B32":     {floatHeader(0, 6), single(0, 0, 0, 7, 8, 9), hdr.Color{R: 7, G: 8, B: 9, A: 1}},
+		"R16G16B16A16":  {floatHeader(0, 10), []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0x3C, 0, 0, 0, 0, 0, 0x38}, hdr.Color{R: 1, A: .5}},
+		"R16":           {floatHeader(0, 54), append(half(0), half(0x3800)...), hdr.Color{R: .5, A: 1}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, hdr.ColorModel, ColorModel(test.header, hdr.ToneMapNone))
+			img, err := New(test.header).Decode(bytes.NewReader(test.data))
+			assert.NoError(t, err)
+			if assert.IsType(t, &hdr.Image{}, img) {
+				assert.Equal(t, test.pixel, img.(*hdr.Image).FloatAt(1, 0))
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeFloatToneMap(t *testing.T) {
+	h := floatHeader(116, 0)
+	assert.Equal(t, color.NRGBAModel, ColorModel(h, hdr.ToneMapReinhard))
+
+	data := make([]byte, 32)
+	binary.LittleEndian.PutUint32(data[16:], math.Float32bits(3))
+	binary.LittleEndian.PutUint32(data[28:], math.Float32bits(1))
+
+	d := New(h)
+	d.ToneMap = hdr.ToneMapReinhard
+	img, err := d.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), img.Bounds())
+	assert.Equal(t, color.NRGBA{R: 191, A: 255}, img.At(1, 0))
+}
@@ -0,0 +1,68 @@
+package uncompressed
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+type (
+	// rgba16 decodes pixels with four unsigned 16 bit channels in RGBA order into an *image.NRGBA64.
+	rgba16 struct{}
+
+	// cxv8u8 decodes pixels with two signed 8 bit channels of a normal into an *image.NRGBA. The third channel is
+	// computed, so that the normal has unit length.
+	cxv8u8 struct {
+		mapping SignedMapping
+	}
+)
+
+// findInteger returns the format of textures with a legacy numeric D3DFMT code for integer channels.
+func findInteger(h *header.Header, m SignedMapping) format {
+	if !h.PixelFlags.Has(header.DDPFFourCC) {
+		return nil
+	}
+	switch header.D3DFormat(h.FourCC) {
+	case header.D3DFMTA16B16G16R16:
+		return &rgba16{}
+	case header.D3DFMTQ16W16V16U16:
+		return &signed{channels: 4, wide: true, mapping: m}
+	case header.D3DFMTCxV8U8:
+		return &cxv8u8{mapping: m}
+	}
+	return nil
+}
+
+func (*rgba16) PixelSize() int { return 8 }
+
+func (*rgba16) ColorModel() color.Model { return color.NRGBA64Model }
+
+func (*rgba16) New(bounds image.Rectangle) draw.Image { return image.NewNRGBA64(bounds) }
+
+func (*rgba16) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*image.NRGBA64)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)]
+	for i := 0; i < len(p); i += 2 {
+		p[i+0], p[i+1] = src[i+1], src[i] // NRGBA64 is big endian
+	}
+}
+
+func (*cxv8u8) PixelSize() int { return 2 }
+
+func (*cxv8u8) ColorModel() color.Model { return color.NRGBAModel }
+
+func (*cxv8u8) New(bounds image.Rectangle) draw.Image { return image.NewNRGBA(bounds) }
+
+func (f *cxv8u8) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*image.NRGBA)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)*2]
+	for i, j := 0, 0; j < len(src); i, j = i+4, j+2 {
+		u, v := int8(src[j]), int8(src[j+1])
+		fu, fv := normalize(int32(u), math.MaxInt8), normalize(int32(v), math.MaxInt8)
+		c := int8(math.Sqrt(float64(max(0, 1-fu*fu-fv*fv)))*math.MaxInt8 + .5)
+		p[i+0], p[i+1], p[i+2], p[i+3] = f.mapping.channel8(u), f.mapping.channel8(v), f.mapping.channel8(c), 0xFF
+	}
+}
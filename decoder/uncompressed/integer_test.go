@@ -0,0 +1,64 @@
+package uncompressed
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_DecodeInteger(t *testing.T) {
+	var tests = map[string]struct {
+		format header.D3DFormat
+		data   []byte
+		pixel  color.Color
+		model  color.Model
+	}{
+		"A16B16G16R16": {
+			header.D3DFMTA16B16G16R16,
+			[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0x34, 0x12, 0xFF, 0xFF, 0, 0, 0x00, 0x80},
+			color.NRGBA64{R: 0x1234, G: 0xFFFF, B: 0, A: 0x8000},
+			color.NRGBA64Model,
+		},
+		"Q16W16V16U16": {
+			header.D3DFMTQ16W16V16U16,
+			[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0x7F, 0x01, 0x80, 0x00, 0x80, 0, 0},
+			color.NRGBA64{R: 0xFFFF, G: 0, B: 0, A: 0x8000},
+			color.NRGBA64Model,
+		},
+		"CxV8U8": {
+			header.D3DFMTCxV8U8,
+			[]byte{0, 0, 0x81, 0},
+			color.NRGBA{R: 0, G: 128, B: 128, A: 255},
+			color.NRGBAModel,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := floatHeader(uint32(test.format), 0)
+			assert.Equal(t, test.model, ColorModel(h, 0))
+			img, err := New(h).Decode(bytes.NewReader(test.data))
+			assert.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 2, 1), img.Bounds())
+			assert.Equal(t, test.pixel, img.At(1, 0))
+		})
+	}
+}
+
+func TestSignedMapping(t *testing.T) {
+	assert.Equal(t, uint16(0), SignedBias.channel16(-32768))
+	assert.Equal(t, uint16(0), SignedBias.channel16(-32767))
+	assert.Equal(t, uint16(0x8000), SignedBias.channel16(0))
+	assert.Equal(t, uint16(0xFFFF), SignedBias.channel16(32767))
+	assert.Equal(t, uint8(0), SignedBias.channel8(-128))
+	assert.Equal(t, uint8(128), SignedBias.channel8(0))
+	assert.Equal(t, uint8(255), SignedBias.channel8(127))
+
+	assert.Equal(t, uint16(0x8001), SignedRaw.channel16(-32767))
+	assert.Equal(t, uint8(0x81), SignedRaw.channel8(-127))
+	assert.Equal(t, uint8(0x7F), SignedRaw.channel8(127))
+}
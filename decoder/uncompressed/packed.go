@@ -0,0 +1,101 @@
+package uncompressed
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+type (
+	// rgb10a2 decodes 32 bit pixels with three 10 bit channels and 2 bit alpha into an *image.NRGBA64. The UINT
+	// variant is normalized like the UNORM one.
+	rgb10a2 struct {
+		shifts [3]uint // position of the red, green and blue channel, alpha is always stored in the top bits
+	}
+
+	// packedFloat decodes 32 bit pixels with packed float channels into an *hdr.Image.
+	packedFloat struct {
+		sharedExp bool // R9G9B9E5_SHAREDEXP instead of R11G11B10_FLOAT
+	}
+)
+
+// findPacked returns the format of textures with channels packed into 32 bits.
+func findPacked(h *header.Header, _ SignedMapping) format {
+	if h.PixelFlags.Has(header.DDPFFourCC) {
+		if h.FourCCString != header.FourCCDX10 {
+			return nil
+		}
+		switch h.DxgiFormat {
+		case 24, 25: // DXGI_FORMAT_R10G10B10A2_UNORM, DXGI_FORMAT_R10G10B10A2_UINT
+			return &rgb10a2{shifts: [3]uint{0, 10, 20}}
+		case 26: // DXGI_FORMAT_R11G11B10_FLOAT
+			return &packedFloat{}
+		case 67: // DXGI_FORMAT_R9G9B9E5_SHAREDEXP
+			return &packedFloat{sharedExp: true}
+		}
+		return nil
+	}
+
+	if !h.PixelFlags.Has(header.DDPFRGB) || h.RgbBitCount != 32 {
+		return nil
+	}
+	switch [4]uint32{h.RBitMask, h.GBitMask, h.BBitMask, h.ABitMask} {
+	case [4]uint32{0x3FF, 0xFFC00, 0x3FF00000, 0xC0000000}: // D3DFMT_A2B10G10R10
+		return &rgb10a2{shifts: [3]uint{0, 10, 20}}
+	case [4]uint32{0x3FF00000, 0xFFC00, 0x3FF, 0xC0000000}: // D3DFMT_A2R10G10B10
+		return &rgb10a2{shifts: [3]uint{20, 10, 0}}
+	}
+	return nil
+}
+
+func (*rgb10a2) PixelSize() int { return 4 }
+
+func (*rgb10a2) ColorModel() color.Model { return color.NRGBA64Model }
+
+func (*rgb10a2) New(bounds image.Rectangle) draw.Image { return image.NewNRGBA64(bounds) }
+
+func (f *rgb10a2) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*image.NRGBA64)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)*2]
+	for i, j := 0, 0; j < len(src); i, j = i+8, j+4 {
+		v := binary.LittleEndian.Uint32(src[j:])
+		for c, shift := range f.shifts {
+			ch := v >> shift & 0x3FF
+			ch = ch<<6 | ch>>4 // expand to 16 bit
+			p[i+2*c], p[i+2*c+1] = byte(ch>>8), byte(ch)
+		}
+		a := v >> 30 * 0x5555
+		p[i+6], p[i+7] = byte(a>>8), byte(a)
+	}
+}
+
+func (*packedFloat) PixelSize() int { return 4 }
+
+func (*packedFloat) ColorModel() color.Model { return hdr.ColorModel }
+
+func (*packedFloat) New(bounds image.Rectangle) draw.Image { return hdr.NewImage(bounds) }
+
+func (f *packedFloat) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*hdr.Image)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)]
+	for i := 0; i < len(src); i += 4 {
+		v := binary.LittleEndian.Uint32(src[i:])
+		if f.sharedExp {
+			// the mantissas have no implicit leading one and 9 bits after the binary point
+			scale := float32(math.Ldexp(1, int(v>>27)-15-9))
+			p[i+0] = float32(v&0x1FF) * scale
+			p[i+1] = float32(v>>9&0x1FF) * scale
+			p[i+2] = float32(v>>18&0x1FF) * scale
+		} else {
+			p[i+0] = minifloat(v&0x7FF, 6)
+			p[i+1] = minifloat(v>>11&0x7FF, 6)
+			p[i+2] = minifloat(v>>22, 5)
+		}
+		p[i+3] = 1
+	}
+}
@@ -0,0 +1,96 @@
+package uncompressed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinifloat(t *testing.T) {
+	var tests = []struct {
+		v        uint32
+		mantissa uint
+		f        float32
+	}{
+		{0x000, 6, 0},
+		{0x3C0, 6, 1},     // exponent 15
+		{0x3E0, 6, 1.5},   // exponent 15, mantissa 0b100000
+		{0x7BF, 6, 65024}, // largest 11 bit float
+		{0x001, 6, 1. / (1 << 20)},
+		{0x1E0, 5, 1}, // exponent 15 with 10 bits
+		{0x3E0, 5, float32(math.Inf(1))},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.f, minifloat(test.v, test.mantissa), "%#x", test.v)
+	}
+	assert.True(t, math.IsNaN(float64(minifloat(0x7C1, 6))))
+}
+
+// packed returns the little endian bytes of the given 32 bit pixels.
+func packed(pixels ...uint32) (b []byte) {
+	for _, v := range pixels {
+		b = binary.LittleEndian.AppendUint32(b, v)
+	}
+	return
+}
+
+func TestDecoder_DecodePacked(t *testing.T) {
+	masks := func(r, g, b, a uint32) *header.Header {
+		h := rgbHeader(32, header.DDPFRGB|header.DDPFAlphaPixels, 0)
+		h.Width, h.Height = 2, 1
+		h.RBitMask, h.GBitMask, h.BBitMask, h.ABitMask = r, g, b, a
+		return h
+	}
+	pixel := uint32(1)<<30 | 0x3FF<<20 | 0x200<<10 | 0x000
+
+	var tests = map[string]struct {
+		header *header.Header
+		data   []byte
+		pixel  color.Color
+	}{
+		"R10G10B10A2_UNORM": {floatHeader(0, 24), packed(0, pixel), color.NRGBA64{R: 0, G: 0x8020, B: 0xFFFF, A: 0x5555}},
+		"R10G10B10A2_UINT":  {floatHeader(0, 25), packed(0, pixel), color.NRGBA64{R: 0, G: 0x8020, B: 0xFFFF, A: 0x5555}},
+		"A2B10G10R10": {
+			masks(0x3FF, 0xFFC00, 0x3FF00000, 0xC0000000), packed(0, pixel),
+			color.NRGBA64{R: 0, G: 0x8020, B: 0xFFFF, A: 0x5555},
+		},
+		"A2R10G10B10": {
+			masks(0x3FF00000, 0xFFC00, 0x3FF, 0xC0000000), packed(0, pixel),
+			color.NRGBA64{R: 0xFFFF, G: 0x8020, B: 0, A: 0x5555},
+		},
+		"R11G11B10_FLOAT": {
+			floatHeader(0, 26), packed(0, 0x1E0<<22|0x3E0<<11|0x3C0),
+			hdr.Color{R: 1, G: 1.5, B: 1, A: 1},
+		},
+		"R9G9B9E5_SHAREDEXP": {
+			floatHeader(0, 67), packed(0, 16<<27|0x1FF<<18|0x100<<9|0x080),
+			hdr.Color{R: .5, G: 1, B: 511. / 256, A: 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, Supported(test.header))
+			img, err := New(test.header).Decode(bytes.NewReader(test.data))
+			assert.NoError(t, err)
+			assert.Equal(t, test.pixel, img.At(1, 0))
+		})
+	}
+}
+
+func TestFindRGB_Masks(t *testing.T) {
+	h := rgbHeader(32, header.DDPFRGB|header.DDPFAlphaPixels, 0)
+	h.RBitMask, h.GBitMask, h.BBitMask, h.ABitMask = 0xFF, 0xFF00, 0xFF0000, 0xFF000000
+	img, err := New(h).Decode(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8, 0, 0, 0, 0, 0, 0, 0, 0}))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 5, G: 6, B: 7, A: 8}, img.At(1, 0))
+
+	h.RBitMask = 0x7C00 // not 8 bits per channel
+	assert.False(t, Supported(h))
+}
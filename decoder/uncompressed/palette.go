@@ -0,0 +1,62 @@
+package uncompressed
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+type (
+	// p8 decodes 8 bit palette indices into an *image.Paletted.
+	p8 struct {
+		palette color.Palette
+	}
+
+	// a8p8 decodes 8 bit palette indices followed by 8 bit alpha into an *image.NRGBA, replacing the alpha of the
+	// palette colors.
+	a8p8 struct {
+		palette color.Palette
+	}
+)
+
+// findPalette returns the format of palette indexed textures (DDPFPaletteIndexed8).
+func findPalette(h *header.Header, _ SignedMapping) format {
+	if !h.PixelFlags.Has(header.DDPFPaletteIndexed8) || len(h.Palette) != 256 {
+		return nil
+	}
+	switch h.RgbBitCount {
+	case 8:
+		return &p8{palette: h.Palette}
+	case 16:
+		return &a8p8{palette: h.Palette}
+	}
+	return nil
+}
+
+func (*p8) PixelSize() int { return 1 }
+
+func (f *p8) ColorModel() color.Model { return f.palette }
+
+func (f *p8) New(bounds image.Rectangle) draw.Image { return image.NewPaletted(bounds, f.palette) }
+
+func (*p8) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*image.Paletted)
+	copy(img.Pix[y*img.Stride:], src)
+}
+
+func (*a8p8) PixelSize() int { return 2 }
+
+func (*a8p8) ColorModel() color.Model { return color.NRGBAModel }
+
+func (*a8p8) New(bounds image.Rectangle) draw.Image { return image.NewNRGBA(bounds) }
+
+func (f *a8p8) Row(dst draw.Image, y int, src []byte) {
+	img := dst.(*image.NRGBA)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)*2]
+	for i, j := 0, 0; j < len(src); i, j = i+4, j+2 {
+		c := f.palette[src[j]].(color.NRGBA)
+		p[i+0], p[i+1], p[i+2], p[i+3] = c.R, c.G, c.B, src[j+1]
+	}
+}
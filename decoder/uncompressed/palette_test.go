@@ -0,0 +1,36 @@
+package uncompressed
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_DecodePalette(t *testing.T) {
+	palette := make(color.Palette, 256)
+	for i := range palette {
+		palette[i] = color.NRGBA{R: byte(i), G: 2, B: 3, A: 200}
+	}
+	h := rgbHeader(8, header.DDPFPaletteIndexed8, 0)
+	h.Palette = palette
+
+	assert.Equal(t, palette, ColorModel(h, 0))
+	img, err := New(h).Decode(bytes.NewReader([]byte{0, 1, 2, 255}))
+	assert.NoError(t, err)
+	if assert.IsType(t, &image.Paletted{}, img) {
+		assert.Equal(t, []byte{0, 1, 2, 255}, img.(*image.Paletted).Pix)
+	}
+	assert.Equal(t, color.NRGBA{R: 255, G: 2, B: 3, A: 200}, img.At(1, 1))
+
+	h.RgbBitCount, h.PixelFlags.F = 16, header.DDPFPaletteIndexed8|header.DDPFAlphaPixels
+	img, err = New(h).Decode(bytes.NewReader([]byte{0, 0, 7, 50, 0, 0, 0, 0}))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 7, G: 2, B: 3, A: 50}, img.At(1, 0))
+
+	h.Palette = nil
+	assert.False(t, Supported(h))
+}
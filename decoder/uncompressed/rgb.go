@@ -0,0 +1,63 @@
+package uncompressed
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// bgr decodes 24 bit BGR and 32 bit BGRX, BGRA, RGBX or RGBA pixels into an *image.NRGBA.
+type bgr struct {
+	size  int  // bytes per pixel, 3 or 4
+	alpha bool // the fourth byte is alpha, otherwise it is ignored
+	rgb   bool // the channels are stored in RGB order instead of BGR
+}
+
+// findRGB returns the format of RGB textures with 24 or 32 bits per pixel and 8 bits per channel. Textures without
+// masks are expected in BGR(A) order.
+func findRGB(h *header.Header, _ SignedMapping) format {
+	if h.PixelFlags.Has(header.DDPFFourCC) || !h.PixelFlags.Has(header.DDPFRGB) ||
+		(h.RgbBitCount != 24 && h.RgbBitCount != 32) {
+		return nil
+	}
+
+	f := &bgr{size: int(h.RgbBitCount / 8), alpha: h.RgbBitCount == 32 && h.PixelFlags.Has(header.DDPFAlphaPixels)}
+	switch [3]uint32{h.RBitMask, h.GBitMask, h.BBitMask} {
+	case [3]uint32{0, 0, 0}, [3]uint32{0xFF0000, 0xFF00, 0xFF}:
+	case [3]uint32{0xFF, 0xFF00, 0xFF0000}:
+		f.rgb = true
+	default:
+		return nil
+	}
+	if f.alpha && h.ABitMask != 0 && h.ABitMask != 0xFF000000 {
+		return nil
+	}
+	return f
+}
+
+func (f *bgr) PixelSize() int { return f.size }
+
+func (*bgr) ColorModel() color.Model { return color.NRGBAModel }
+
+func (*bgr) New(bounds image.Rectangle) draw.Image { return image.NewNRGBA(bounds) }
+
+func (f *bgr) Row(dst draw.Image, y int, src []byte) {
+	rgba := dst.(*image.NRGBA)
+	p := rgba.Pix[y*rgba.Stride : y*rgba.Stride+len(src)/f.size*4]
+	r, b := 2, 0
+	if f.rgb {
+		r, b = 0, 2
+	}
+	// BGRA to RGBA re-order.
+	for i, j := 0, 0; i < len(p); i, j = i+4, j+f.size {
+		p[i+0] = src[j+r]
+		p[i+1] = src[j+1]
+		p[i+2] = src[j+b]
+		p[i+3] = 0xFF
+		if f.alpha {
+			p[i+3] = src[j+3]
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package uncompressed
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// SignedMapping selects how signed normalized channels in [-1, 1] are mapped to the unsigned channels of an image.
+type SignedMapping byte
+
+// supported mappings of signed channels
+const (
+	SignedBias SignedMapping = iota // scale and bias [-1, 1] to [0, 1], like normal maps are usually displayed
+	SignedRaw                       // keep the stored two's complement bits, so that -1 is 0x81 and 0 is 0x00
+)
+
+// channel8 maps the signed 8 bit channel v.
+func (m SignedMapping) channel8(v int8) uint8 {
+	if m == SignedRaw {
+		return uint8(v)
+	}
+	return uint8((normalize(int32(v), math.MaxInt8)+1)/2*math.MaxUint8 + .5)
+}
+
+// channel16 maps the signed 16 bit channel v.
+func (m SignedMapping) channel16(v int16) uint16 {
+	if m == SignedRaw {
+		return uint16(v)
+	}
+	return uint16((normalize(int32(v), math.MaxInt16)+1)/2*math.MaxUint16 + .5)
+}
+
+// normalize returns the signed normalized value v with the given maximum as float in [-1, 1]. The minimal value
+// is one less than -maximum and also maps to -1.
+func normalize(v, maximum int32) float32 {
+	return max(float32(v)/float32(maximum), -1)
+}
+
+// signed decodes pixels with signed normalized channels in RGBA order into an *image.NRGBA, or an *image.NRGBA64
+// for 16 bit channels. Missing color channels are zero before the mapping and a missing alpha channel is opaque.
+type signed struct {
+	channels int  // number of stored channels
+	wide     bool // 16 bit channels instead of 8 bit
+	mapping  SignedMapping
+}
+
+// bump formats by their bits per pixel and masks
+var bumpFormats = map[[3]uint32]signed{
+	{16, 0xFF, 0xFF00}:       {channels: 2},             // D3DFMT_V8U8
+	{32, 0xFF, 0xFF00}:       {channels: 4},             // D3DFMT_Q8W8V8U8
+	{32, 0xFFFF, 0xFFFF0000}: {channels: 2, wide: true}, // D3DFMT_V16U16
+}
+
+// DXGI signed normalized formats
+var signedDXGI = map[uint32]signed{
+	13: {channels: 4, wide: true}, // DXGI_FORMAT_R16G16B16A16_SNORM
+	31: {channels: 4},             // DXGI_FORMAT_R8G8B8A8_SNORM
+	37: {channels: 2, wide: true}, // DXGI_FORMAT_R16G16_SNORM
+	51: {channels: 2},             // DXGI_FORMAT_R8G8_SNORM
+	58: {channels: 1, wide: true}, // DXGI_FORMAT_R16_SNORM
+	63: {channels: 1},             // DXGI_FORMAT_R8_SNORM
+}
+
+// findSigned returns the format of bump map textures (DDPFBumpDuDv) and DXGI signed normalized formats.
+func findSigned(h *header.Header, m SignedMapping) format {
+	var f signed
+	var ok bool
+	switch {
+	case h.PixelFlags.Has(header.DDPFFourCC) && h.FourCCString == header.FourCCDX10:
+		f, ok = signedDXGI[h.DxgiFormat]
+	case h.PixelFlags.Has(header.DDPFBumpDuDv) && !h.PixelFlags.Has(header.DDPFFourCC):
+		f, ok = bumpFormats[[3]uint32{h.RgbBitCount, h.RBitMask, h.GBitMask}]
+	}
+	if !ok {
+		return nil
+	}
+	f.mapping = m
+	return &f
+}
+
+func (f *signed) PixelSize() int {
+	if f.wide {
+		return 2 * f.channels
+	}
+	return f.channels
+}
+
+func (f *signed) ColorModel() color.Model {
+	if f.wide {
+		return color.NRGBA64Model
+	}
+	return color.NRGBAModel
+}
+
+func (f *signed) New(bounds image.Rectangle) draw.Image {
+	if f.wide {
+		return image.NewNRGBA64(bounds)
+	}
+	return image.NewNRGBA(bounds)
+}
+
+func (f *signed) Row(dst draw.Image, y int, src []byte) {
+	if f.wide {
+		img := dst.(*image.NRGBA64)
+		p := img.Pix[y*img.Stride : y*img.Stride+len(src)/f.channels*4]
+		for i, j := 0, 0; i < len(p); i += 8 {
+			for c := 0; c < 4; c++ {
+				v := f.mapping.channel16(0)
+				if c < f.channels {
+					v = f.mapping.channel16(int16(binary.LittleEndian.Uint16(src[j:])))
+					j += 2
+				} else if c == 3 {
+					v = 0xFFFF
+				}
+				p[i+2*c], p[i+2*c+1] = byte(v>>8), byte(v) // NRGBA64 is big endian
+			}
+		}
+		return
+	}
+
+	img := dst.(*image.NRGBA)
+	p := img.Pix[y*img.Stride : y*img.Stride+len(src)/f.channels*4]
+	for i, j := 0, 0; i < len(p); i += 4 {
+		for c := 0; c < 4; c++ {
+			v := f.mapping.channel8(0)
+			if c < f.channels {
+				v = f.mapping.channel8(int8(src[j]))
+				j++
+			} else if c == 3 {
+				v = 0xFF
+			}
+			p[i+c] = v
+		}
+	}
+}
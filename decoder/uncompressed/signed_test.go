@@ -0,0 +1,63 @@
+package uncompressed
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_DecodeSigned(t *testing.T) {
+	bump := func(bits, r, g uint32) *header.Header {
+		h := rgbHeader(bits, header.DDPFBumpDuDv, 0)
+		h.Width, h.Height = 2, 1
+		h.RBitMask, h.GBitMask = r, g
+		return h
+	}
+
+	var tests = map[string]struct {
+		header *header.Header
+		data   []byte
+		bias   color.Color
+		raw    color.Color
+	}{
+		"V8U8": {
+			bump(16, 0xFF, 0xFF00), []byte{0, 0, 0x81, 0x7F},
+			color.NRGBA{R: 0, G: 255, B: 128, A: 255}, color.NRGBA{R: 0x81, G: 0x7F, B: 0, A: 255},
+		},
+		"Q8W8V8U8": {
+			bump(32, 0xFF, 0xFF00), []byte{0, 0, 0, 0, 0x7F, 0, 0x81, 0x80},
+			color.NRGBA{R: 255, G: 128, B: 0, A: 0}, color.NRGBA{R: 0x7F, G: 0, B: 0x81, A: 0x80},
+		},
+		"V16U16": {
+			bump(32, 0xFFFF, 0xFFFF0000), []byte{0, 0, 0, 0, 0x01, 0x80, 0xFF, 0x7F},
+			color.NRGBA64{R: 0, G: 0xFFFF, B: 0x8000, A: 0xFFFF}, color.NRGBA64{R: 0x8001, G: 0x7FFF, B: 0, A: 0xFFFF},
+		},
+		"R8G8_SNORM": {
+			floatHeader(0, 51), []byte{0, 0, 0x7F, 0x81},
+			color.NRGBA{R: 255, G: 0, B: 128, A: 255}, color.NRGBA{R: 0x7F, G: 0x81, B: 0, A: 255},
+		},
+		"R8_SNORM": {
+			floatHeader(0, 63), []byte{0, 0x7F},
+			color.NRGBA{R: 255, G: 128, B: 128, A: 255}, color.NRGBA{R: 0x7F, A: 255},
+		},
+		"R16G16B16A16_SNORM": {
+			floatHeader(0, 13), []byte{0, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0x7F, 0, 0, 0, 0, 0x01, 0x80},
+			color.NRGBA64{R: 0xFFFF, G: 0x8000, B: 0x8000, A: 0}, color.NRGBA64{R: 0x7FFF, A: 0x8001},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for m, pixel := range map[SignedMapping]color.Color{SignedBias: test.bias, SignedRaw: test.raw} {
+				d := &Decoder{Signed: m}
+				d.Reset(test.header)
+				img, err := d.Decode(bytes.NewReader(test.data))
+				assert.NoError(t, err)
+				assert.Equal(t, pixel, img.At(1, 0), "mapping %d", m)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package dds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/funatsufumiya/dds-simd/decoder/dxt"
+	"github.com/funatsufumiya/dds-simd/decoder/uncompressed"
+	"github.com/funatsufumiya/dds-simd/hdr"
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTexture returns a dds file with a compressed texture of the given fourCC and size holding payload.
+func newTexture(fourCC string, width, height int, payload []byte) []byte {
+	var h [32]uint32
+	h[0] = binary.LittleEndian.Uint32([]byte("DDS "))
+	h[1] = 124                                         // header size
+	h[2] = 0x1007                                      // caps, height, width, pixel format
+	h[3], h[4] = uint32(height), uint32(width)         // height, width
+	h[19] = 32                                         // pixel format size
+	h[20] = 0x4                                        // fourCC
+	h[21] = binary.LittleEndian.Uint32([]byte(fourCC)) // fourCC
+	h[27] = 0x1000                                     // texture
+
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.LittleEndian, h)
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	var d Decoder
+	for _, fourCC := range []string{"DXT1", "DXT3", "DXT5"} {
+		size := 8
+		if fourCC != "DXT1" {
+			size = 16
+		}
+		file := newTexture(fourCC, 8, 4, make([]byte, 2*size))
+
+		img, err := d.Decode(bytes.NewReader(file))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 8, 4), img.Bounds())
+	}
+}
+
+func TestDecoder_DecodeToAllocations(t *testing.T) {
+	var d Decoder
+	small := newTexture("DXT5", 4, 4, make([]byte, 16))
+	large := newTexture("DXT5", 256, 256, make([]byte, 64*64*16))
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+
+	allocs := func(file []byte) float64 {
+		rd := bytes.NewReader(file)
+		return testing.AllocsPerRun(10, func() {
+			rd.Reset(file)
+			assert.NoError(t, d.DecodeTo(rd, img))
+		})
+	}
+	// only the header allocates, independent of the amount of blocks
+	n := allocs(small)
+	assert.LessOrEqual(t, n, 9.)
+	assert.Equal(t, n, allocs(large))
+}
+
+func TestDecoder_Profile(t *testing.T) {
+	file := newTexture("DXT1", 4, 4, []byte{0xFF, 0xFF, 0x00, 0x00, 0xE4, 0xE4, 0xE4, 0xE4})
+
+	img, err := Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 170, G: 170, B: 170, A: 255}, img.At(2, 0))
+
+	d := Decoder{Profile: dxt.ProfileNVIDIA}
+	img, err = d.Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 170, G: 175, B: 170, A: 255}, img.At(2, 0))
+
+	d = Decoder{Profile: 9}
+	_, err = d.Decode(bytes.NewReader(file))
+	assert.ErrorIs(t, err, dxt.ErrProfile)
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	var d Decoder
+
+	truncated := newTexture("DXT1", 8, 8, make([]byte, 20))
+	_, err := d.Decode(iotest.OneByteReader(bytes.NewReader(truncated)))
+	var eof *header.TruncatedError
+	if assert.ErrorAs(t, err, &eof) {
+		assert.Equal(t, header.TruncatedError{Section: "blocks", Offset: 128, Expected: 32, Received: 20}, *eof)
+	}
+
+	// readers reporting their length are checked before decoding
+	_, err = d.Decode(bytes.NewReader(truncated))
+	if assert.ErrorAs(t, err, &eof) {
+		assert.Equal(t, header.TruncatedError{
+			Section: "surface (element 0, face 0, mip 0)", Offset: 128, Expected: 32, Received: 20,
+		}, *eof)
+	}
+
+	_, err = d.Decode(bytes.NewReader(newTexture("ATI2", 4, 4, make([]byte, 16))))
+	var format *header.FormatError
+	if assert.ErrorAs(t, err, &format) {
+		assert.Equal(t, "ATI2", format.FourCC)
+	}
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = DecodeConfig(bytes.NewReader(newTexture("ATI2", 4, 4, nil)))
+	assert.ErrorAs(t, err, &format)
+}
+
+func TestDecoder_HeaderMode(t *testing.T) {
+	file := newTexture("DXT1", 4, 4, make([]byte, 8))
+	file[8] = 0x06 // only height and width flags
+
+	var d Decoder
+	_, err := d.Decode(bytes.NewReader(file))
+	assert.ErrorIs(t, err, header.ErrMalformed)
+
+	d.HeaderMode = header.Lenient
+	img, err := d.Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 4), img.Bounds())
+	assert.Len(t, d.Warnings(), 1)
+
+	c, err := d.DecodeConfig(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, c.Width)
+}
+
+func TestDecoder_Limits(t *testing.T) {
+	// a header announcing a huge texture must not allocate it
+	file := newTexture("DXT1", 1<<20, 1<<20, nil)
+
+	var d Decoder
+	_, err := d.Decode(bytes.NewReader(file))
+	assert.ErrorIs(t, err, header.ErrLimit)
+	_, err = DecodeConfig(bytes.NewReader(file))
+	assert.ErrorIs(t, err, header.ErrLimit)
+
+	d.Limits = &header.Limits{MaxWidth: 4}
+	_, err = d.Decode(bytes.NewReader(newTexture("DXT1", 8, 4, make([]byte, 16))))
+	var limit *header.LimitError
+	if assert.ErrorAs(t, err, &limit) {
+		assert.Equal(t, header.LimitError{Field: "Width", Value: 8, Limit: 4}, *limit)
+	}
+}
+
+func TestDecoder_LimitsBytes(t *testing.T) {
+	// a streamed header of 128 bytes announcing a float texture within the dimension limits must not allocate
+	// 4 GiB for it before reading any data
+	file := newTexture("t\x00\x00\x00", 16384, 16384, nil) // A32B32G32R32F
+	assert.Len(t, file, 128)
+	stream := func() io.Reader { return struct{ io.Reader }{bytes.NewReader(file)} }
+
+	var d Decoder
+	var limit *header.LimitError
+	_, err := d.Decode(stream())
+	if assert.ErrorAs(t, err, &limit) {
+		assert.Equal(t, header.LimitError{Field: "Bytes", Value: 16384 * 16384 * 16, Limit: 256 << 20}, *limit)
+	}
+	d.ToneMap = hdr.ToneMapClamp
+	_, err = d.Decode(stream())
+	assert.ErrorIs(t, err, header.ErrLimit, "1 GiB of 8 bit pixels")
+	_, err = d.DecodeRegion(stream(), image.Rect(0, 0, 1<<20, 1<<20))
+	assert.ErrorIs(t, err, header.ErrLimit)
+
+	// small parts of it are fine
+	_, err = d.DecodeRegion(stream(), image.Rect(0, 0, 16, 16))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.NotErrorIs(t, err, header.ErrLimit)
+
+	d.Limits = &header.Limits{}
+	_, err = d.DecodeConfig(stream())
+	assert.NoError(t, err, "zero limits are disabled")
+}
+
+func TestDecoder_DecodeUncompressed(t *testing.T) {
+	file := newTexture("\x00\x00\x00\x00", 2, 1, []byte{3, 2, 1, 6, 5, 4, 0, 0})
+	binary.LittleEndian.PutUint32(file[8:], 0x100F) // with pitch
+	binary.LittleEndian.PutUint32(file[5*4:], 8)    // padded pitch
+	binary.LittleEndian.PutUint32(file[20*4:], 0x40)
+	binary.LittleEndian.PutUint32(file[22*4:], 24)
+
+	c, err := DecodeConfig(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBAModel, c.ColorModel)
+
+	img, err := Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 4, G: 5, B: 6, A: 255}, img.At(1, 0))
+}
+
+func TestDecoder_DecodeFloat(t *testing.T) {
+	file := newTexture("o\x00\x00\x00", 1, 1, []byte{0x00, 0x3C}) // R16F
+
+	img, err := Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, hdr.Color{R: 1, A: 1}, img.At(0, 0))
+
+	d := Decoder{ToneMap: hdr.ToneMapClamp}
+	c, err := d.DecodeConfig(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBAModel, c.ColorModel)
+	img, err = d.Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, img.At(0, 0))
+}
+
+func TestDecoder_Signed(t *testing.T) {
+	file := newTexture("\x00\x00\x00\x00", 1, 1, []byte{0x81, 0x7F}) // V8U8
+	binary.LittleEndian.PutUint32(file[20*4:], 0x80000)
+	binary.LittleEndian.PutUint32(file[22*4:], 16)
+	binary.LittleEndian.PutUint32(file[23*4:], 0xFF)
+	binary.LittleEndian.PutUint32(file[24*4:], 0xFF00)
+
+	var d Decoder
+	img, err := d.Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0, G: 255, B: 128, A: 255}, img.At(0, 0))
+
+	d.Signed = uncompressed.SignedRaw
+	img, err = d.Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0x81, G: 0x7F, A: 255}, img.At(0, 0))
+}
+
+func TestDecoder_DecodePalette(t *testing.T) {
+	palette := make([]byte, 1024)
+	copy(palette[3*4:], []byte{10, 20, 30, 255})
+	file := newTexture("\x00\x00\x00\x00", 2, 1, append(palette, 0, 3))
+	binary.LittleEndian.PutUint32(file[20*4:], 0x20)
+	binary.LittleEndian.PutUint32(file[22*4:], 8)
+
+	img, err := Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+	assert.IsType(t, &image.Paletted{}, img)
+	assert.Equal(t, color.NRGBA{R: 10, G: 20, B: 30, A: 255}, img.At(1, 0))
+	assert.NoError(t, Validate(bytes.NewReader(file)))
+}
+
+func TestDecodeRegion(t *testing.T) {
+	// a white block followed by a black one, the stream ends right after the region
+	white := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	file := newTexture("DXT1", 8, 8, white)
+
+	img, err := DecodeRegion(bytes.NewReader(file), image.Rect(1, 1, 3, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(1, 1, 3, 3), img.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, img.At(2, 2))
+
+	_, err = DecodeRegion(bytes.NewReader(file), image.Rect(4, 0, 5, 1))
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, header.TruncatedError{Section: "blocks", Offset: 128, Expected: 32, Received: 8}, *truncated)
+	}
+}
+
+func TestDecoder_DecodeAt(t *testing.T) {
+	white := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	atlas := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+
+	var d Decoder
+	assert.NoError(t, d.DecodeAt(bytes.NewReader(newTexture("DXT1", 4, 4, white)), atlas, image.Pt(4, 4)))
+	assert.Equal(t, color.NRGBA{}, atlas.NRGBAAt(3, 3))
+	assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, atlas.NRGBAAt(4, 4))
+
+	err := d.DecodeAt(bytes.NewReader(newTexture("DXT1", 4, 4, white)), atlas, image.Pt(6, 0))
+	assert.ErrorIs(t, err, header.ErrBounds)
+}
+
+func TestWriteNRGBA(t *testing.T) {
+	file := newTexture("DXT1", 8, 8, bytes.Repeat([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}, 4))
+	img, err := Decode(bytes.NewReader(file))
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteNRGBA(bytes.NewReader(file), &out))
+	assert.Equal(t, img.(*image.NRGBA).Pix, out.Bytes())
+
+	// 16 bit channels are converted
+	h := make([]byte, 128)
+	copy(h, newTexture("DXT1", 2, 5, nil))
+	binary.LittleEndian.PutUint32(h[84:], 36) // D3DFMT_A16B16G16R16
+	pixels := bytes.Repeat([]byte{0, 0xff, 0, 0x80, 0, 0, 0xff, 0xff}, 10)
+	out.Reset()
+	assert.NoError(t, WriteNRGBA(bytes.NewReader(append(h, pixels...)), &out))
+	assert.Equal(t, bytes.Repeat([]byte{0xff, 0x80, 0, 0xff}, 10), out.Bytes())
+
+	bands := 0
+	err = DecodeBands(bytes.NewReader(append(h, pixels...)), func(band image.Image) error {
+		bands++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bands)
+}
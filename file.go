@@ -0,0 +1,137 @@
+package dds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+
+	"github.com/funatsufumiya/dds-simd/decoder/dxt"
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// ErrNoSurface is wrapped by the errors about surfaces that a texture does not have.
+var ErrNoSurface = errors.New("no such surface")
+
+// File gives random access to the surfaces of a dds file. Only the header is read when opening it, every surface is
+// read from its offset on demand. A File must not be used concurrently.
+type File struct {
+	Header   *header.Header
+	Surfaces []header.Surface // all surfaces in the order they are stored, see header.Header.Surfaces
+
+	r io.ReaderAt
+	d Decoder
+}
+
+// Open reads the header of the dds file stored in r, which may e.g. be an *os.File or a memory mapped file.
+// Textures in a format with an unknown storage size produce an error wrapping ErrUnsupported.
+func Open(r io.ReaderAt) (*File, error) {
+	return new(Decoder).Open(r)
+}
+
+// Open reads the header of a dds file like the package level Open. The surfaces of the returned File are decoded
+// with the configuration of the decoder at the time it is opened.
+func (d *Decoder) Open(r io.ReaderAt) (*File, error) {
+	h, err := d.header(io.NewSectionReader(r, 0, math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	surfaces, err := h.Surfaces()
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Header: h, Surfaces: surfaces, r: r, d: *d}
+	f.d.d = nil // the decoding state is not shared
+	return f, nil
+}
+
+// Surface returns the surface of the given array element, cube map face and mip map level. Textures without the
+// surface produce an error wrapping ErrNoSurface.
+func (f *File) Surface(index, face, mip int) (header.Surface, error) {
+	for _, s := range f.Surfaces {
+		if s.Index == index && s.Face == face && s.Mip == mip {
+			return s, nil
+		}
+	}
+	return header.Surface{}, fmt.Errorf("%w: element %d, face %d, mip %d", ErrNoSurface, index, face, mip)
+}
+
+// Read returns the undecoded data of the surface s. A surface that is not completely stored in the file produces a
+// *header.TruncatedError, which is detected before allocating if the io.ReaderAt reports its size like a
+// *bytes.Reader or an *io.SectionReader does. A surface exceeding the byte limit of the decoder produces a
+// *header.LimitError.
+func (f *File) Read(s header.Surface) ([]byte, error) {
+	offset := f.Header.Size() + s.Offset
+	truncated := func(n int64) error {
+		return &header.TruncatedError{Section: s.String(), Offset: offset, Expected: s.Size, Received: n}
+	}
+	if r, ok := f.r.(interface{ Size() int64 }); ok && r.Size() < offset+s.Size {
+		return nil, truncated(max(0, r.Size()-offset))
+	}
+	if err := f.d.limits().CheckBytes(uint64(s.Size)); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, s.Size)
+	n, err := f.r.ReadAt(data, offset)
+	if n == len(data) {
+		return data, nil
+	} else if err == io.EOF {
+		return nil, truncated(int64(n))
+	}
+	return nil, err
+}
+
+// Decode decodes the surface s into a new image of its size, reading only the data of s. Of volume textures the
+// first slice is decoded.
+func (f *File) Decode(s header.Surface) (image.Image, error) {
+	return f.DecodeContext(context.Background(), s)
+}
+
+// DecodeContext decodes the surface s like Decode, but stops with ctx.Err() once ctx is done. The context is checked
+// between rows of blocks or pixels, so that decoding the surfaces of a large texture array one by one can be
+// cancelled at any time.
+func (f *File) DecodeContext(ctx context.Context, s header.Surface) (image.Image, error) {
+	offset, h := f.Header.Size()+s.Offset, f.Header.SurfaceHeader(s)
+	if err := f.d.allocate(h, image.Rect(0, 0, s.Width, s.Height)); err != nil {
+		return nil, err
+	}
+	if err := f.d.prepare(h, offset); err != nil {
+		return nil, err
+	}
+	img, err := f.d.d.Decode(f.d.reader(ctx, io.NewSectionReader(f.r, offset, s.Size), h))
+	return img, f.d.locate(err)
+}
+
+// DecodeRegion decodes the part of the surface s within rect like Decode, reading only the rows of blocks or pixels
+// covering it.
+func (f *File) DecodeRegion(s header.Surface, rect image.Rectangle) (image.Image, error) {
+	return f.DecodeRegionContext(context.Background(), s, rect)
+}
+
+// DecodeRegionContext decodes the part of the surface s within rect like DecodeRegion, but stops with ctx.Err() once
+// ctx is done, see DecodeContext.
+func (f *File) DecodeRegionContext(ctx context.Context, s header.Surface, rect image.Rectangle) (image.Image, error) {
+	offset, h := f.Header.Size()+s.Offset, f.Header.SurfaceHeader(s)
+	if err := f.d.allocate(h, rect); err != nil {
+		return nil, err
+	}
+	if err := f.d.prepare(h, offset); err != nil {
+		return nil, err
+	}
+	img, err := f.d.d.DecodeRegion(f.d.reader(ctx, io.NewSectionReader(f.r, offset, s.Size), h), rect)
+	return img, f.d.locate(err)
+}
+
+// Image returns an image of the surface s that only reads and decodes the blocks of the pixels accessed, see
+// dxt.Image, so that huge textures can be drawn from with little memory. Only DXT compressed textures are supported,
+// for others an error wrapping ErrUnsupported is returned.
+func (f *File) Image(s header.Surface) (*dxt.Image, error) {
+	if !f.Header.PixelFlags.Has(header.DDPFFourCC) {
+		return nil, header.NewFormatError(f.Header, "not a DXT format")
+	}
+	r := io.NewSectionReader(f.r, f.Header.Size()+s.Offset, s.Size)
+	return dxt.NewImage(r, f.Header.FourCCString, s.Width, s.Height, f.d.Profile)
+}
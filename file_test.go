@@ -0,0 +1,106 @@
+package dds
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+// readerAt hides all methods of a *bytes.Reader apart from ReadAt
+type readerAt struct{ r *bytes.Reader }
+
+func (r readerAt) ReadAt(p []byte, off int64) (int, error) { return r.r.ReadAt(p, off) }
+
+func TestFile(t *testing.T) {
+	// an opaque white 8x8 mip map followed by a black 4x4 one
+	white := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	payload := append(bytes.Repeat(white, 4), 0, 0, 0, 0, 0, 0, 0, 0)
+	file := newTexture("DXT1", 8, 8, payload)
+	file[8+2] |= 0x2 // DDSDMipMapCount
+	file[7*4] = 2    // mip map count
+
+	f, err := Open(bytes.NewReader(file))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, f.Surfaces, 2)
+
+	s, err := f.Surface(0, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, header.Surface{Mip: 1, Width: 4, Height: 4, Depth: 1, Pitch: 8, Offset: 32, Size: 8}, s)
+	data, err := f.Read(s)
+	assert.NoError(t, err)
+	assert.Equal(t, payload[32:], data)
+
+	img, err := f.Decode(s)
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 4, 4), img.Bounds())
+		assert.Equal(t, color.NRGBA{A: 255}, img.At(3, 3))
+	}
+	img, err = f.Decode(f.Surfaces[0])
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 8, 8), img.Bounds())
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, img.At(7, 7))
+	}
+
+	img, err = f.DecodeRegion(f.Surfaces[0], image.Rect(6, 6, 9, 9))
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(6, 6, 8, 8), img.Bounds())
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, img.At(6, 6))
+	}
+
+	lazy, err := f.Image(s)
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 4, 4), lazy.Bounds())
+		assert.Equal(t, color.NRGBA{A: 255}, lazy.At(3, 3))
+	}
+
+	_, err = f.Surface(0, 1, 0)
+	assert.ErrorIs(t, err, ErrNoSurface)
+
+	// the last mip map is incomplete, with and without knowing the size up front
+	short := file[:len(file)-3]
+	for _, r := range []interface {
+		ReadAt([]byte, int64) (int, error)
+	}{bytes.NewReader(short), readerAt{bytes.NewReader(short)}} {
+		f, err = Open(r)
+		assert.NoError(t, err)
+		_, err = f.Read(f.Surfaces[1])
+		var truncated *header.TruncatedError
+		if assert.ErrorAs(t, err, &truncated) {
+			assert.Equal(t, header.TruncatedError{
+				Section: "surface (element 0, face 0, mip 1)", Offset: 160, Expected: 8, Received: 5,
+			}, *truncated)
+		}
+
+		_, err = f.Decode(f.Surfaces[1])
+		if assert.ErrorAs(t, err, &truncated) {
+			assert.Equal(t, int64(160), truncated.Offset)
+		}
+	}
+}
+
+func TestFile_Limits(t *testing.T) {
+	file := newTexture("DXT1", 8, 8, make([]byte, 32))
+	d := Decoder{Limits: &header.Limits{MaxBytes: 128}}
+	f, err := d.Open(readerAt{bytes.NewReader(file)})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the 256 bytes of the decoded image exceed the limit, the 32 bytes of the surface do not
+	_, err = f.Decode(f.Surfaces[0])
+	assert.ErrorIs(t, err, header.ErrLimit)
+	_, err = f.DecodeRegion(f.Surfaces[0], image.Rect(0, 0, 4, 4))
+	assert.NoError(t, err)
+	_, err = f.Read(f.Surfaces[0])
+	assert.NoError(t, err)
+
+	f.d.Limits = &header.Limits{MaxBytes: 16}
+	_, err = f.Read(f.Surfaces[0])
+	assert.ErrorIs(t, err, header.ErrLimit)
+}
@@ -0,0 +1,152 @@
+// Package hdr provides an image type with float channels for high dynamic range textures and the tone mapping of
+// such images to 8 bit.
+package hdr
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Color is a linear color with float channels. It is not alpha-premultiplied and the channels may exceed [0, 1].
+type Color struct {
+	R, G, B, A float32
+}
+
+// RGBA returns the alpha-premultiplied color with the channels clamped to [0, 1].
+func (c Color) RGBA() (r, g, b, a uint32) {
+	a = scale(c.A)
+	return scale(c.R) * a / 0xFFFF, scale(c.G) * a / 0xFFFF, scale(c.B) * a / 0xFFFF, a
+}
+
+// scale clamps v to [0, 1] and scales it to 16 bit.
+func scale(v float32) uint32 {
+	if !(v > 0) { // also catches NaN
+		return 0
+	} else if v >= 1 {
+		return 0xFFFF
+	}
+	return uint32(v*0xFFFF + .5)
+}
+
+// ColorModel converts colors to Color.
+var ColorModel = color.ModelFunc(func(c color.Color) color.Color {
+	if c, ok := c.(Color); ok {
+		return c
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return Color{}
+	}
+	fa := float32(a)
+	return Color{R: float32(r) / fa, G: float32(g) / fa, B: float32(b) / fa, A: fa / 0xFFFF}
+})
+
+// Image is an in-memory image whose At method returns Color values.
+type Image struct {
+	// Pix holds the image's pixels in R, G, B, A order. The pixel at (x, y) starts at
+	// Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*4].
+	Pix []float32
+	// Stride is the Pix stride (in floats) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+}
+
+// NewImage returns a new Image with the given bounds.
+func NewImage(r image.Rectangle) *Image {
+	return &Image{Pix: make([]float32, 4*r.Dx()*r.Dy()), Stride: 4 * r.Dx(), Rect: r}
+}
+
+func (p *Image) ColorModel() color.Model { return ColorModel }
+
+func (p *Image) Bounds() image.Rectangle { return p.Rect }
+
+func (p *Image) At(x, y int) color.Color {
+	return p.FloatAt(x, y)
+}
+
+// FloatAt returns the color of the pixel at (x, y) without conversion.
+func (p *Image) FloatAt(x, y int) Color {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return Color{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	return Color{R: s[0], G: s[1], B: s[2], A: s[3]}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to the pixel at (x, y).
+func (p *Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+func (p *Image) Set(x, y int, c color.Color) {
+	p.SetFloat(x, y, ColorModel.Convert(c).(Color))
+}
+
+// SetFloat sets the pixel at (x, y) to c without conversion.
+func (p *Image) SetFloat(x, y int, c Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	s[0], s[1], s[2], s[3] = c.R, c.G, c.B, c.A
+}
+
+// SubImage returns an image representing the portion of the image p visible through r. The returned value shares
+// pixels with the original image.
+func (p *Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &Image{}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &Image{Pix: p.Pix[i:], Stride: p.Stride, Rect: r}
+}
+
+// ToneMap selects how the channels of a Color are mapped to 8 bit. The alpha channel is always clamped.
+type ToneMap byte
+
+// supported tone mappings
+const (
+	ToneMapNone     ToneMap = iota // no tone mapping, float textures are decoded into an *Image
+	ToneMapClamp                   // clamp the channels to [0, 1]
+	ToneMapReinhard                // map [0, ∞) to [0, 1) with v / (1 + v)
+)
+
+// NRGBA maps c to an 8 bit color. ToneMapNone clamps like ToneMapClamp.
+func (t ToneMap) NRGBA(c Color) color.NRGBA {
+	return color.NRGBA{R: t.channel(c.R), G: t.channel(c.G), B: t.channel(c.B), A: byte(scale(c.A) >> 8)}
+}
+
+// channel maps a single color channel to 8 bit.
+func (t ToneMap) channel(v float32) byte {
+	if t == ToneMapReinhard && v > 0 && !math.IsInf(float64(v), 1) {
+		v /= 1 + v
+	}
+	if !(v > 0) {
+		return 0
+	} else if v >= 1 {
+		return 255
+	}
+	return byte(v*255 + .5)
+}
+
+// Image maps all pixels of src to a new 8 bit image.
+func (t ToneMap) Image(src *Image) *image.NRGBA {
+	dst := image.NewNRGBA(src.Rect)
+	t.Draw(dst, src)
+	return dst
+}
+
+// Draw maps the pixels of src to the pixels of dst at the same coordinates, as far as dst covers them.
+func (t ToneMap) Draw(dst *image.NRGBA, src *Image) {
+	r := src.Rect.Intersect(dst.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetNRGBA(x, y, t.NRGBA(src.FloatAt(x, y)))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package hdr
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColor_RGBA(t *testing.T) {
+	r, g, b, a := Color{R: 2, G: .5, B: -1, A: .5}.RGBA()
+	assert.Equal(t, [4]uint32{0x8000, 0x4000, 0, 0x8000}, [4]uint32{r, g, b, a})
+
+	r, _, _, _ = Color{R: float32(math.NaN()), A: 1}.RGBA()
+	assert.Zero(t, r)
+}
+
+func TestColorModel(t *testing.T) {
+	assert.Equal(t, Color{R: 1, G: 0, B: .2, A: 1}, ColorModel.Convert(color.NRGBA{R: 255, B: 51, A: 255}))
+	assert.Equal(t, Color{R: 3, A: 1}, ColorModel.Convert(Color{R: 3, A: 1}))
+}
+
+func TestImage(t *testing.T) {
+	img := NewImage(image.Rect(1, 2, 3, 4))
+	img.SetFloat(2, 3, Color{R: 4, G: 5, B: 6, A: .5})
+	img.Set(1, 2, color.NRGBA{R: 255, A: 255})
+	img.SetFloat(5, 5, Color{R: 1}) // outside
+
+	assert.Equal(t, Color{R: 4, G: 5, B: 6, A: .5}, img.At(2, 3))
+	assert.Equal(t, Color{R: 1, A: 1}, img.FloatAt(1, 2))
+	assert.Equal(t, Color{}, img.FloatAt(0, 0))
+	assert.Equal(t, []float32{1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 4, 5, 6, .5}, img.Pix)
+}
+
+func TestImage_SubImage(t *testing.T) {
+	img := NewImage(image.Rect(0, 0, 3, 3))
+	img.SetFloat(2, 1, Color{R: 1, A: 1})
+
+	sub := img.SubImage(image.Rect(1, 1, 5, 2)).(*Image)
+	assert.Equal(t, image.Rect(1, 1, 3, 2), sub.Bounds())
+	assert.Equal(t, Color{R: 1, A: 1}, sub.FloatAt(2, 1))
+	assert.Equal(t, Color{}, sub.FloatAt(0, 0), "outside of the sub image")
+
+	sub.SetFloat(1, 1, Color{G: 1})
+	assert.Equal(t, Color{G: 1}, img.FloatAt(1, 1), "the pixels are shared")
+	assert.True(t, img.SubImage(image.Rect(4, 4, 5, 5)).Bounds().Empty())
+}
+
+func TestToneMap(t *testing.T) {
+	c := Color{R: 3, G: 1, B: -2, A: 2}
+	assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 0, A: 255}, ToneMapClamp.NRGBA(c))
+	assert.Equal(t, color.NRGBA{R: 191, G: 128, B: 0, A: 255}, ToneMapReinhard.NRGBA(c))
+	assert.Equal(t, ToneMapClamp.NRGBA(c), ToneMapNone.NRGBA(c))
+
+	img := NewImage(image.Rect(0, 0, 2, 1))
+	img.SetFloat(1, 0, Color{R: 1, G: float32(math.Inf(1)), A: 1})
+	nrgba := ToneMapReinhard.Image(img)
+	assert.Equal(t, color.NRGBA{R: 128, G: 255, A: 255}, nrgba.At(1, 0))
+	assert.Equal(t, color.NRGBA{}, nrgba.At(0, 0))
+
+	dst := image.NewNRGBA(image.Rect(1, 0, 3, 1))
+	ToneMapClamp.Draw(dst, img)
+	assert.Equal(t, []byte{255, 255, 0, 255, 0, 0, 0, 0}, dst.Pix)
+}
@@ -0,0 +1,43 @@
+package header
+
+import (
+	"encoding/binary"
+)
+
+// D3DFormat is a legacy Direct3D 9 format. Formats without a character code are stored with their numeric value
+// as DDPFHeader.FourCC.
+type D3DFormat uint32
+
+// numeric D3DFMT codes found as FourCC
+const (
+	D3DFMTA16B16G16R16  D3DFormat = 36  // four unsigned 16 bit channels
+	D3DFMTQ16W16V16U16  D3DFormat = 110 // four signed 16 bit channels
+	D3DFMTR16F          D3DFormat = 111 // one 16 bit float channel
+	D3DFMTG16R16F       D3DFormat = 112 // two 16 bit float channels
+	D3DFMTA16B16G16R16F D3DFormat = 113 // four 16 bit float channels
+	D3DFMTR32F          D3DFormat = 114 // one 32 bit float channel
+	D3DFMTG32R32F       D3DFormat = 115 // two 32 bit float channels
+	D3DFMTA32B32G32R32F D3DFormat = 116 // four 32 bit float channels
+	D3DFMTCxV8U8        D3DFormat = 117 // two signed 8 bit channels, the third one is computed
+)
+
+// d3dFormats holds the names of the numeric D3DFMT codes
+var d3dFormats = map[D3DFormat]string{
+	D3DFMTA16B16G16R16:  "A16B16G16R16",
+	D3DFMTQ16W16V16U16:  "Q16W16V16U16",
+	D3DFMTR16F:          "R16F",
+	D3DFMTG16R16F:       "G16R16F",
+	D3DFMTA16B16G16R16F: "A16B16G16R16F",
+	D3DFMTR32F:          "R32F",
+	D3DFMTG32R32F:       "G32R32F",
+	D3DFMTA32B32G32R32F: "A32B32G32R32F",
+	D3DFMTCxV8U8:        "CxV8U8",
+}
+
+// String returns the name of the format without the D3DFMT_ prefix, or the raw FourCC bytes for unknown formats.
+func (f D3DFormat) String() string {
+	if name, ok := d3dFormats[f]; ok {
+		return name
+	}
+	return string(binary.LittleEndian.AppendUint32(nil, uint32(f)))
+}
@@ -0,0 +1,27 @@
+package header
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestD3DFormat_String(t *testing.T) {
+	assert.Equal(t, "A16B16G16R16", D3DFMTA16B16G16R16.String())
+	assert.Equal(t, "CxV8U8", D3DFMTCxV8U8.String())
+	assert.Equal(t, "@\x00\x00\x00", D3DFormat(64).String())
+	assert.Equal(t, "DXT1", D3DFormat(binary.LittleEndian.Uint32([]byte("DXT1"))).String())
+}
+
+func TestRead_D3DFormat(t *testing.T) {
+	data := validHeader("")
+	data[21*4] = byte(D3DFMTQ16W16V16U16)
+
+	h, err := Read(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "Q16W16V16U16", h.FourCCString)
+	assert.EqualValues(t, D3DFMTQ16W16V16U16, h.FourCC)
+	assert.EqualError(t, NewFormatError(h, ""), `unsupported texture format: FourCC "Q16W16V16U16"`)
+}
@@ -0,0 +1,152 @@
+package header
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+var (
+	// ErrMalformed is wrapped by all errors about a header that was read completely but is not valid.
+	ErrMalformed = errors.New("malformed header")
+
+	// ErrUnsupported is wrapped by all errors about valid textures in a format that cannot be decoded.
+	ErrUnsupported = errors.New("unsupported texture format")
+
+	// ErrLimit is wrapped by all errors about textures exceeding the configured Limits.
+	ErrLimit = errors.New("texture exceeds limits")
+
+	// ErrTrailingData is wrapped by all errors about data following the last surface of a texture.
+	ErrTrailingData = errors.New("trailing data")
+
+	// ErrBounds is wrapped by all errors about images that cannot hold a texture decoded into them.
+	ErrBounds = errors.New("texture out of bounds")
+)
+
+// HeaderError reports an invalid header field. It wraps ErrMalformed.
+type HeaderError struct {
+	Field  string // name of the field, e.g. "HeaderSize" or "PixelFlags"
+	Value  any    // the value read from the file
+	Reason string // what is wrong with the value
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("%v: %s is %v: %s", ErrMalformed, e.Field, e.Value, e.Reason)
+}
+
+func (e *HeaderError) Unwrap() error {
+	return ErrMalformed
+}
+
+// FormatError reports a texture format that is not supported. It wraps ErrUnsupported.
+type FormatError struct {
+	FourCC     string // FourCC of the texture if it has one
+	DxgiFormat uint32 // the DXGI format of textures with a DX10 header
+	PixelFlags DDPFf  // the pixel format flags
+	Reason     string // optional details
+}
+
+// NewFormatError returns a FormatError describing the format of the texture h.
+func NewFormatError(h *Header, reason string) *FormatError {
+	e := &FormatError{PixelFlags: h.PixelFlags.F, Reason: reason}
+	if h.PixelFlags.Has(DDPFFourCC) {
+		e.FourCC = h.FourCCString
+	}
+	if e.FourCC == FourCCDX10 {
+		e.DxgiFormat = h.DxgiFormat
+	}
+	return e
+}
+
+func (e *FormatError) Error() string {
+	msg := ErrUnsupported.Error()
+	switch {
+	case e.FourCC == FourCCDX10:
+		msg += fmt.Sprintf(": DXGI format %d", e.DxgiFormat)
+	case e.FourCC != "":
+		msg += fmt.Sprintf(": FourCC %q", e.FourCC)
+	default:
+		msg += fmt.Sprintf(": pixel flags %#x", uint32(e.PixelFlags))
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+func (e *FormatError) Unwrap() error {
+	return ErrUnsupported
+}
+
+// TruncatedError reports a stream that ended before a section of the file was complete. It wraps
+// io.ErrUnexpectedEOF.
+type TruncatedError struct {
+	Section  string // the incomplete part of the file, e.g. "header" or "blocks"
+	Offset   int64  // position of the section in the file
+	Expected int64  // expected size of the section in bytes
+	Received int64  // bytes of the section read before the stream ended
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("%v: %s at offset %d expected %d bytes, got %d",
+		io.ErrUnexpectedEOF, e.Section, e.Offset, e.Expected, e.Received)
+}
+
+func (e *TruncatedError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// LimitError reports a texture exceeding one of the Limits. It wraps ErrLimit.
+type LimitError struct {
+	Field string // the exceeded limit, e.g. "Width" or "Pixels"
+	Value uint64 // the value of the texture
+	Limit uint64 // the configured limit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s is %d, limit is %d", ErrLimit, e.Field, e.Value, e.Limit)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimit
+}
+
+// TrailingDataError reports data following the last surface of a texture. It wraps ErrTrailingData.
+type TrailingDataError struct {
+	Offset int64 // position of the data in the file
+	Size   int64 // bytes of the data
+}
+
+func (e *TrailingDataError) Error() string {
+	return fmt.Sprintf("%v: %d bytes at offset %d", ErrTrailingData, e.Size, e.Offset)
+}
+
+func (e *TrailingDataError) Unwrap() error {
+	return ErrTrailingData
+}
+
+// BoundsError reports a destination image that does not cover the pixels a texture is decoded into. It wraps
+// ErrBounds.
+type BoundsError struct {
+	Texture image.Rectangle // the pixels of the destination the texture would be decoded into
+	Dst     image.Rectangle // the bounds of the destination image
+}
+
+func (e *BoundsError) Error() string {
+	return fmt.Sprintf("%v: texture at %v does not fit into image %v", ErrBounds, e.Texture, e.Dst)
+}
+
+func (e *BoundsError) Unwrap() error {
+	return ErrBounds
+}
+
+// CheckBounds returns a *BoundsError if an image with the bounds dst does not cover a texture of the given size
+// placed at the point p.
+func CheckBounds(dst image.Rectangle, p image.Point, width, height int) error {
+	r := image.Rect(p.X, p.Y, p.X+width, p.Y+height)
+	if !r.In(dst) {
+		return &BoundsError{Texture: r, Dst: dst}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package header
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBounds(t *testing.T) {
+	dst := image.Rect(10, 10, 20, 20)
+	assert.NoError(t, CheckBounds(dst, image.Pt(10, 10), 10, 10))
+	assert.NoError(t, CheckBounds(dst, image.Pt(0, 0), 0, 0))
+
+	err := CheckBounds(dst, image.Pt(12, 10), 10, 4)
+	assert.ErrorIs(t, err, ErrBounds)
+	assert.EqualError(t, err, "texture out of bounds: texture at (12,10)-(22,14) does not fit into image (10,10)-(20,20)")
+}
@@ -29,12 +29,15 @@ type DDPFf uint32
 
 // flags for the DDPFHeader.PixelFlags itself
 const (
-	DDPFAlphaPixels DDPFf = 0x1     // texture contains alpha data
-	DDPFAlpha       DDPFf = 0x2     // texture contains only uncompressed alpha data
-	DDPFFourCC      DDPFf = 0x4     // texture contains compressed RGB data
-	DDPFRGB         DDPFf = 0x40    // texture contains uncompressed RGB data
-	DDPFYUV         DDPFf = 0x200   // texture contains uncompressed YUV data
-	DDPFLuminance   DDPFf = 0x20000 // texture contains a single channel uncompressed data
+	DDPFAlphaPixels     DDPFf = 0x1     // texture contains alpha data
+	DDPFAlpha           DDPFf = 0x2     // texture contains only uncompressed alpha data
+	DDPFFourCC          DDPFf = 0x4     // texture contains compressed RGB data
+	DDPFPaletteIndexed8 DDPFf = 0x20    // texture contains 8 bit indices into a palette following the header
+	DDPFRGB             DDPFf = 0x40    // texture contains uncompressed RGB data
+	DDPFYUV             DDPFf = 0x200   // texture contains uncompressed YUV data
+	DDPFLuminance       DDPFf = 0x20000 // texture contains a single channel uncompressed data
+	DDPFBumpLuminance   DDPFf = 0x40000 // texture contains signed bump map data and luminance (DDPF_BUMPLUMINANCE)
+	DDPFBumpDuDv        DDPFf = 0x80000 // texture contains signed bump map or normal data (DDPF_BUMPDUDV)
 )
 
 // DDSCf is the flag type for CapsHeader.Caps1
@@ -47,6 +50,21 @@ const (
 	DDSCAPSTexture DDSCf = 0x1000
 )
 
+// flags for the second dword of the CapsHeader.Caps2
+const (
+	DDSCAPS2Cubemap          uint32 = 0x200    // texture is a cube map
+	DDSCAPS2CubemapPositiveX uint32 = 0x400    // cube map contains the +x face
+	DDSCAPS2CubemapNegativeX uint32 = 0x800    // cube map contains the -x face
+	DDSCAPS2CubemapPositiveY uint32 = 0x1000   // cube map contains the +y face
+	DDSCAPS2CubemapNegativeY uint32 = 0x2000   // cube map contains the -y face
+	DDSCAPS2CubemapPositiveZ uint32 = 0x4000   // cube map contains the +z face
+	DDSCAPS2CubemapNegativeZ uint32 = 0x8000   // cube map contains the -z face
+	DDSCAPS2Volume           uint32 = 0x200000 // texture is a volume texture
+)
+
+// DDSResourceMiscTextureCube is set in the DX10Header.MiscFlag of cube maps
+const DDSResourceMiscTextureCube uint32 = 0x4
+
 // DDSDTc is the dimension flag for a DC10 texture
 type DDSDTc uint32
 
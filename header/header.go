@@ -1,5 +1,9 @@
 package header
 
+import (
+	"image/color"
+)
+
 type (
 	// Header holds the combined used header information according to the specifications.
 	Header struct {
@@ -7,7 +11,9 @@ type (
 		DDPFHeader
 		CapsHeader
 		DX10Header
-		FourCCString string // the string representation of the DDPFHeader.FourCC
+		FourCCString string        // the characters of the DDPFHeader.FourCC, or the name of a numeric D3DFormat
+		Palette      color.Palette // the 256 colors of palette indexed textures (DDPFPaletteIndexed8)
+		Warnings     []Warning     // repairs of a malformed header applied in the Lenient mode
 	}
 
 	// DDSHeader is the definition header for the dds texture file
@@ -52,6 +58,19 @@ type (
 	Flags[f ~uint32] struct{ F f }
 )
 
+// Size returns the size of the serialized header in bytes, which is the offset of the texture data in the file.
+// It includes the DX10 header and the palette of palette indexed textures.
+func (h *Header) Size() int64 {
+	size := int64(sizeDDTF)
+	if h.FourCCString == FourCCDX10 {
+		size += sizeDX10
+	}
+	if h.PixelFlags.Has(DDPFPaletteIndexed8) {
+		size += sizePalette
+	}
+	return size
+}
+
 // Has returns if the flags contain all given bits
 func (d Flags[f]) Has(v f) bool {
 	return d.F&v == v
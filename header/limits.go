@@ -0,0 +1,68 @@
+package header
+
+// Limits bound the size of the textures that are accepted, so that a small hostile file cannot request huge
+// allocations. A limit of zero disables the check.
+type Limits struct {
+	MaxWidth     uint32 // maximal width of a texture in pixels
+	MaxHeight    uint32 // maximal height of a texture in pixels
+	MaxDepth     uint32 // maximal depth of a volume texture in pixels
+	MaxPixels    uint64 // maximal pixels of a single surface, width × height × depth
+	MaxArraySize uint32 // maximal number of textures in a DX10 texture array
+	MaxMipCount  uint32 // maximal number of mip map levels
+	MaxBytes     uint64 // maximal size of a single allocation for the pixels or data of a surface
+}
+
+// DefaultLimits follow the resource limits of Direct3D 11 for the dimensions, but allow at most 256 MiB per
+// decoded image, e.g. 8192×8192 pixels of 8 bit RGBA or 4096×4096 of float RGBA. Larger textures can still be
+// decoded into an image of the caller, by region, or in bands.
+var DefaultLimits = Limits{
+	MaxWidth:     16384,
+	MaxHeight:    16384,
+	MaxDepth:     2048,
+	MaxPixels:    16384 * 16384,
+	MaxArraySize: 2048,
+	MaxMipCount:  15,
+	MaxBytes:     256 << 20,
+}
+
+// Check returns a *LimitError if the texture described by h exceeds the limits. The mip map count is only checked
+// if DDSDMipMapCount is set and the array size only for textures with a DX10 header. MaxBytes is checked by
+// CheckBytes.
+func (l *Limits) Check(h *Header) error {
+	check := func(field string, value, limit uint64) error {
+		if limit != 0 && value > limit {
+			return &LimitError{Field: field, Value: value, Limit: limit}
+		}
+		return nil
+	}
+
+	pixels := uint64(h.Width) * uint64(h.Height) * uint64(max(h.Depth, 1))
+	errs := []error{
+		check("Width", uint64(h.Width), uint64(l.MaxWidth)),
+		check("Height", uint64(h.Height), uint64(l.MaxHeight)),
+		check("Depth", uint64(h.Depth), uint64(l.MaxDepth)),
+		check("Pixels", pixels, l.MaxPixels),
+	}
+	if h.TextureFlags.Has(DDSDMipMapCount) {
+		errs = append(errs, check("MipMapCount", uint64(h.MipMapCount), uint64(l.MaxMipCount)))
+	}
+	if h.FourCCString == FourCCDX10 {
+		errs = append(errs, check("ArraySize", uint64(h.ArraySize), uint64(l.MaxArraySize)))
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckBytes returns a *LimitError if allocating the given number of bytes exceeds MaxBytes. It is checked before
+// the pixels of a surface are allocated, as their size depends on how the texture is decoded.
+func (l *Limits) CheckBytes(bytes uint64) error {
+	if l.MaxBytes != 0 && bytes > l.MaxBytes {
+		return &LimitError{Field: "Bytes", Value: bytes, Limit: l.MaxBytes}
+	}
+	return nil
+}
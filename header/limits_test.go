@@ -0,0 +1,57 @@
+package header
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits_Check(t *testing.T) {
+	texture := func(width, height uint32) *Header {
+		return &Header{DDSHeader: DDSHeader{Width: width, Height: height}}
+	}
+	mipMaps := texture(16, 16)
+	mipMaps.TextureFlags.F, mipMaps.MipMapCount = DDSDMipMapCount, 20
+	array := texture(16, 16)
+	array.FourCCString, array.ArraySize = FourCCDX10, 4096
+	volume := texture(16384, 16384)
+	volume.Depth = 2
+
+	var tests = map[string]struct {
+		header *Header
+		field  string
+	}{
+		"valid":        {header: texture(16384, 16384)},
+		"wide":         {header: texture(16385, 1), field: "Width"},
+		"high":         {header: texture(1, 1<<31), field: "Height"},
+		"volume":       {header: volume, field: "Pixels"},
+		"mip maps":     {header: mipMaps, field: "MipMapCount"},
+		"array":        {header: array, field: "ArraySize"},
+		"ignored mips": {header: &Header{DDSHeader: DDSHeader{MipMapCount: 1000}}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := DefaultLimits.Check(test.header)
+			if test.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var limit *LimitError
+			if assert.ErrorAs(t, err, &limit) {
+				assert.Equal(t, test.field, limit.Field)
+			}
+			assert.ErrorIs(t, err, ErrLimit)
+		})
+	}
+
+	assert.NoError(t, new(Limits).Check(texture(1<<31, 1<<31)), "zero limits are disabled")
+}
+
+func TestLimits_CheckBytes(t *testing.T) {
+	assert.NoError(t, DefaultLimits.CheckBytes(256<<20))
+	err := DefaultLimits.CheckBytes(256<<20 + 1)
+	assert.Equal(t, &LimitError{Field: "Bytes", Value: 256<<20 + 1, Limit: 256 << 20}, err)
+	assert.ErrorIs(t, err, ErrLimit)
+	assert.NoError(t, new(Limits).CheckBytes(1<<40), "zero limits are disabled")
+}
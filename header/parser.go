@@ -4,15 +4,17 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"image/color"
 	"io"
 )
 
 const (
-	sizeDDTF   = 128    // Size of the whole texture file header. is 128
-	sizeDDSD   = 124    // Size of the serialized DDSHeader. is 124
-	sizeDDPF   = 32     // Size of the serialized DDPFHeader. is 32
-	sizeDX10   = 20     // Size of the serialized optional DX10Header. is 20
-	FourCCDX10 = "DX10" // the fourCC string for the presence of the extra DX10 header
+	sizeDDTF    = 128    // Size of the whole texture file header. is 128
+	sizeDDSD    = 124    // Size of the serialized DDSHeader. is 124
+	sizeDDPF    = 32     // Size of the serialized DDPFHeader. is 32
+	sizeDX10    = 20     // Size of the serialized optional DX10Header. is 20
+	sizePalette = 1024   // Size of the palette of palette indexed textures. is 1024
+	FourCCDX10  = "DX10" // the fourCC string for the presence of the extra DX10 header
 )
 
 // deserializer is used to parse all header bytes into a structure
@@ -27,67 +29,208 @@ type deserializer struct {
 	_               [1]uint32  // reserved2
 }
 
+// parser holds the state of deserializing a single header
+type parser struct {
+	deserializer
+	lenient  bool      // repair malformed headers instead of rejecting them
+	warnings []Warning // the applied repairs
+}
+
+// Mode selects how headers violating the specification are treated.
+type Mode byte
+
+// supported parsing modes
+const (
+	Strict  Mode = iota // reject headers violating the specification
+	Lenient             // repair headers with missing flags or wrong sizes and report each repair as a Warning
+)
+
+// Options configure the parsing of headers. The zero value parses strictly.
+type Options struct {
+	Mode Mode
+}
+
+// Warning describes a repair of a malformed header applied in the Lenient mode.
+type Warning struct {
+	Field string // name of the repaired field
+	Value any    // the value read from the file
+	Fix   string // what was done about it
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s is %v: %s", w.Field, w.Value, w.Fix)
+}
+
 // Read tries to take 128 Bytes from the reader and then tries to create a Header from it.
+//
+// The reader is read until the header is complete, short reads are no problem. If the reader is empty, io.EOF
+// is returned. If it ends within the header, a *TruncatedError is returned. Headers that are complete but
+// invalid produce a *HeaderError.
 func Read(r io.Reader) (*Header, error) {
-	return new(deserializer).read(r)
+	return ReadWith(r, nil)
+}
+
+// ReadWith reads a header like Read with the given options, which may be nil.
+//
+// In the Lenient mode, headers missing required flags or reporting wrong sizes are repaired if the data allows it,
+// which many exporters require. The applied repairs are listed in Header.Warnings. Headers that cannot be repaired,
+// e.g. with a wrong magic number or without a size, are still rejected.
+func ReadWith(r io.Reader, o *Options) (*Header, error) {
+	if o == nil {
+		o = new(Options)
+	}
+	return (&parser{lenient: o.Mode == Lenient}).read(r)
 }
 
 // read tries to take sizeDDTF Bytes from the reader and then tries to create a Header from it.
 // If it finds the FourCCDX10 header on the DDPFHeader.FourCC it will try to parse the DX10Header.
 // Calls verification on a successful parsed Header, which might return an error in the case of a
 // wrongly configured header.
-func (d *deserializer) read(r io.Reader) (*Header, error) {
-	if err := d.readChunk(r, sizeDDTF, d); err != nil {
+func (d *parser) read(r io.Reader) (*Header, error) {
+	if err := d.readChunk(r, "header", 0, sizeDDTF, &d.deserializer); err != nil {
 		return nil, err
 	} else if err = d.verify(); err != nil {
 		return nil, err
 	}
-
 	header := &Header{
 		DDSHeader:    d.DDSHeader,
 		DDPFHeader:   d.DDPFHeader,
 		CapsHeader:   d.CapsHeader,
-		FourCCString: d.toString(d.FourCC),
+		FourCCString: D3DFormat(d.FourCC).String(),
 	}
 
 	if header.FourCCString == FourCCDX10 {
-		if err := d.readChunk(r, sizeDX10, &header.DX10Header); err != nil {
+		if err := d.readChunk(r, "DX10 header", sizeDDTF, sizeDX10, &header.DX10Header); err == io.EOF {
+			return nil, &TruncatedError{Section: "DX10 header", Offset: sizeDDTF, Expected: sizeDX10}
+		} else if err != nil {
 			return nil, err
 		}
 	}
+	if header.PixelFlags.Has(DDPFPaletteIndexed8) {
+		if err := d.readPalette(r, header); err != nil {
+			return nil, err
+		}
+	}
+	if d.lenient {
+		d.repair(header)
+		header.Warnings = d.warnings
+	}
 	return header, nil
 }
 
-// readChunk reads in a portion of the stream and tries to deserialize it to the given target
-func (*deserializer) readChunk(r io.Reader, size int, target any) error {
+// readChunk reads in a portion of the stream and tries to deserialize it to the given target. It returns io.EOF
+// only if no byte could be read at all.
+func (*deserializer) readChunk(r io.Reader, section string, offset int64, size int, target any) error {
 	buf := make([]byte, size, size)
-	if n, err := r.Read(buf); err != nil {
+	if n, err := io.ReadFull(r, buf); err == io.ErrUnexpectedEOF {
+		return &TruncatedError{Section: section, Offset: offset, Expected: int64(size), Received: int64(n)}
+	} else if err != nil {
 		return err
-	} else if n != size {
-		return fmt.Errorf("bytes in header. expected %d, only got : %d", size, n)
 	}
 	return binary.Read(bytes.NewReader(buf), binary.LittleEndian, target)
 }
 
+// readPalette reads the 256 palette entries following the header of palette indexed textures. The entries are
+// stored as R, G, B, A bytes.
+func (d *parser) readPalette(r io.Reader, h *Header) error {
+	var entries [sizePalette / 4][4]byte
+	offset := h.Size() - sizePalette
+	if err := d.readChunk(r, "palette", offset, sizePalette, &entries); err == io.EOF {
+		return &TruncatedError{Section: "palette", Offset: offset, Expected: sizePalette}
+	} else if err != nil {
+		return err
+	}
+
+	h.Palette = make(color.Palette, len(entries))
+	for i, e := range entries {
+		h.Palette[i] = color.NRGBA{R: e[0], G: e[1], B: e[2], A: e[3]}
+	}
+	return nil
+}
+
+// warn records a repair of the field with the original value, if the parsing is lenient. Otherwise, it returns
+// err, which then needs to be reported.
+func (d *parser) warn(field string, value any, fix string, err error) error {
+	if !d.lenient {
+		return err
+	}
+	d.warnings = append(d.warnings, Warning{Field: field, Value: value, Fix: fix})
+	return nil
+}
+
 // verify makes some semantic checks for validity
-func (d *deserializer) verify() error {
+func (d *parser) verify() error {
 	if mn := d.toString(d.MagicNumber); mn != "DDS " {
-		return fmt.Errorf("magic is incorrect, expected \"DDS \", got %v", mn)
+		return &HeaderError{Field: "MagicNumber", Value: fmt.Sprintf("%q", mn), Reason: `magic is incorrect, expected "DDS "`}
 	}
+	// the layout of the header is fixed, so wrong sizes can be ignored
 	if d.HeaderSize != sizeDDSD {
-		return fmt.Errorf("DDS_HEADER reports wrong size, expected %d, got %d", sizeDDSD, d.HeaderSize)
+		err := &HeaderError{Field: "HeaderSize", Value: d.HeaderSize, Reason: fmt.Sprintf("expected %d", sizeDDSD)}
+		if err := d.warn(err.Field, err.Value, fmt.Sprintf("assumed %d", sizeDDSD), err); err != nil {
+			return err
+		}
+		d.HeaderSize = sizeDDSD
 	}
 	if d.PixelFormatSize != sizeDDPF {
-		return fmt.Errorf("DDS_PIXEL_FORMAT reports wrong size, expected %d, got %d", sizeDDPF, d.PixelFormatSize)
+		err := &HeaderError{Field: "PixelFormatSize", Value: d.PixelFormatSize, Reason: fmt.Sprintf("expected %d", sizeDDPF)}
+		if err := d.warn(err.Field, err.Value, fmt.Sprintf("assumed %d", sizeDDPF), err); err != nil {
+			return err
+		}
+		d.PixelFormatSize = sizeDDPF
 	}
 
 	// check that it's actually a texture per requirements
-	if !d.TextureFlags.Has(DDSDHeaderFlagsTexture) {
-		return fmt.Errorf("DDS_HEADER reports that one or more required fields are not set: flags was %x; should at least have %x set", d.TextureFlags, DDSDHeaderFlagsTexture)
+	if missing := DDSDHeaderFlagsTexture &^ d.TextureFlags.F; missing != 0 {
+		err := &HeaderError{
+			Field:  "TextureFlags",
+			Value:  fmt.Sprintf("%#x", uint32(d.TextureFlags.F)),
+			Reason: fmt.Sprintf("one or more required fields are not set, should at least have %#x set", uint32(DDSDHeaderFlagsTexture)),
+		}
+		// the flags can only be inferred if the texture has a size
+		if d.Width == 0 || d.Height == 0 {
+			return err
+		}
+		if err := d.warn(err.Field, err.Value, fmt.Sprintf("added missing flags %#x", uint32(missing)), err); err != nil {
+			return err
+		}
+		d.TextureFlags.F |= missing
 	}
 	return nil
 }
 
+// repair infers optional flags and sizes of h that are inconsistent with the data, which is only done in the
+// lenient mode as strictly parsed headers are returned as they are.
+func (d *parser) repair(h *Header) {
+	if h.MipMapCount > 1 && !h.TextureFlags.Has(DDSDMipMapCount) {
+		_ = d.warn("TextureFlags", fmt.Sprintf("%#x", uint32(h.TextureFlags.F)),
+			fmt.Sprintf("added flag %#x as there are %d mip maps", uint32(DDSDMipMapCount), h.MipMapCount), nil)
+		h.TextureFlags.F |= DDSDMipMapCount
+	}
+	if h.PixelFlags.F == 0 && h.FourCC != 0 {
+		_ = d.warn("PixelFlags", "0x0", fmt.Sprintf("added flag %#x as the FourCC is set", uint32(DDPFFourCC)), nil)
+		h.PixelFlags.F = DDPFFourCC
+	}
+
+	// many exporters write the pitch of compressed textures or no pitch at all
+	l, err := h.layout()
+	if err != nil {
+		return
+	}
+	pitch := l.pitch(int(h.Width))
+	switch {
+	case h.TextureFlags.Has(DDSDLinearSize) && l.size > 1:
+		if size := pitch * l.rows(int(h.Height)); int64(h.PitchOrLinearSize) != size {
+			_ = d.warn("PitchOrLinearSize", h.PitchOrLinearSize, fmt.Sprintf("replaced by the linear size %d", size), nil)
+			h.PitchOrLinearSize = uint32(size)
+		}
+	case h.TextureFlags.Has(DDSDPitch) && l.size == 1:
+		if int64(h.PitchOrLinearSize) < pitch {
+			_ = d.warn("PitchOrLinearSize", h.PitchOrLinearSize, fmt.Sprintf("replaced by the pitch %d", pitch), nil)
+			h.PitchOrLinearSize = uint32(pitch)
+		}
+	}
+}
+
 func (*deserializer) toString(i uint32) string {
 	return string(binary.LittleEndian.AppendUint32(nil, i))
 }
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/binary"
 	"github.com/stretchr/testify/assert"
+	"image/color"
+	"io"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNew(t *testing.T) {
@@ -56,3 +59,156 @@ func TestDeserializer_Read(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, h)
 }
+
+// validHeader returns a minimal valid header with the given fourCC.
+func validHeader(fourCC string) []byte {
+	var data = make([]byte, sizeDDTF)
+	copy(data, "DDS ")
+	data[1*4] = sizeDDSD
+	binary.LittleEndian.PutUint32(data[2*4:], uint32(DDSDHeaderFlagsTexture))
+	data[19*4] = sizeDDPF
+	data[20*4] = byte(DDPFFourCC)
+	copy(data[21*4:], fourCC)
+	return data
+}
+
+func TestRead_OneByteReader(t *testing.T) {
+	data := append(validHeader(FourCCDX10), 1, 2, 3, 4, 3, 0, 0, 0, 5, 6, 7, 8, 1, 0, 0, 0, 9, 10, 11, 12)
+
+	h, err := Read(iotest.OneByteReader(bytes.NewReader(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, FourCCDX10, h.FourCCString)
+	assert.Equal(t, DX10Header{
+		DxgiFormat:        0x04030201,
+		ResourceDimension: Flags[DDSDTc]{DDSDT2D},
+		MiscFlag:          0x08070605,
+		ArraySize:         1,
+		MiscFlags2:        0x0C0B0A09,
+	}, h.DX10Header)
+}
+
+func TestRead_Errors(t *testing.T) {
+	dx10 := validHeader(FourCCDX10)
+	wrongMagic := validHeader("DXT1")
+	copy(wrongMagic, "DDT ")
+	missingFlags := validHeader("DXT1")
+	missingFlags[2*4] = 0
+
+	var tests = map[string]struct {
+		data    []byte
+		is      error
+		message string
+	}{
+		"empty":          {data: nil, is: io.EOF},
+		"truncated":      {data: dx10[:50], is: io.ErrUnexpectedEOF, message: "expected 128 bytes, got 50"},
+		"missing DX10":   {data: dx10, is: io.ErrUnexpectedEOF, message: "expected 20 bytes, got 0"},
+		"truncated DX10": {data: append(dx10, 1, 2, 3), is: io.ErrUnexpectedEOF, message: "expected 20 bytes, got 3"},
+		"wrong magic":    {data: wrongMagic, is: ErrMalformed, message: "magic is incorrect"},
+		"missing flags":  {data: missingFlags, is: ErrMalformed, message: "required fields are not set"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Read(iotest.OneByteReader(bytes.NewReader(test.data)))
+			assert.ErrorIs(t, err, test.is)
+			assert.Contains(t, err.Error(), test.message)
+			if test.is == io.EOF {
+				assert.Equal(t, io.EOF, err)
+			}
+		})
+	}
+}
+
+func TestRead_ErrorTypes(t *testing.T) {
+	dx10 := validHeader(FourCCDX10)
+	wrongSize := validHeader("DXT1")
+	wrongSize[1*4] = 100
+
+	_, err := Read(bytes.NewReader(append(dx10, 1, 2, 3)))
+	var truncated *TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, TruncatedError{Section: "DX10 header", Offset: 128, Expected: 20, Received: 3}, *truncated)
+	}
+
+	_, err = Read(bytes.NewReader(wrongSize))
+	var malformed *HeaderError
+	if assert.ErrorAs(t, err, &malformed) {
+		assert.Equal(t, "HeaderSize", malformed.Field)
+		assert.Equal(t, uint32(100), malformed.Value)
+	}
+}
+
+func TestNewFormatError(t *testing.T) {
+	h, err := Read(bytes.NewReader(validHeader("ATI2")))
+	assert.NoError(t, err)
+	assert.EqualError(t, NewFormatError(h, ""), `unsupported texture format: FourCC "ATI2"`)
+
+	h.FourCCString, h.DxgiFormat = FourCCDX10, 98
+	assert.EqualError(t, NewFormatError(h, "BC7"), `unsupported texture format: DXGI format 98: BC7`)
+	assert.ErrorIs(t, NewFormatError(h, ""), ErrUnsupported)
+}
+
+func TestReadWith_Lenient(t *testing.T) {
+	missingFlags := validHeader("DXT1")
+	binary.LittleEndian.PutUint32(missingFlags[2*4:], uint32(DDSDHeight|DDSDWidth))
+	missingFlags[3*4], missingFlags[4*4] = 8, 8
+	missingFlags[1*4] = 0
+
+	h, err := ReadWith(bytes.NewReader(missingFlags), &Options{Mode: Lenient})
+	assert.NoError(t, err)
+	assert.True(t, h.TextureFlags.Has(DDSDHeaderFlagsTexture))
+	assert.Equal(t, []Warning{
+		{Field: "HeaderSize", Value: uint32(0), Fix: "assumed 124"},
+		{Field: "TextureFlags", Value: "0x6", Fix: "added missing flags 0x1001"},
+	}, h.Warnings)
+
+	// strict parsing rejects the same header
+	_, err = ReadWith(bytes.NewReader(missingFlags), nil)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestReadWith_LenientRepairs(t *testing.T) {
+	data := validHeader("DXT5")
+	binary.LittleEndian.PutUint32(data[2*4:], uint32(DDSDHeaderFlagsTexture|DDSDLinearSize))
+	data[3*4], data[4*4], data[5*4] = 8, 12, 64 // height, width, pitch of one row
+	data[7*4] = 4                               // mip maps
+	data[20*4] = 0                              // pixel flags
+
+	h, err := ReadWith(bytes.NewReader(data), &Options{Mode: Lenient})
+	assert.NoError(t, err)
+	assert.True(t, h.TextureFlags.Has(DDSDMipMapCount))
+	assert.True(t, h.PixelFlags.Is(DDPFFourCC))
+	assert.EqualValues(t, 3*2*16, h.PitchOrLinearSize)
+	assert.Len(t, h.Warnings, 3)
+
+	// unusable headers are still rejected
+	empty := validHeader("DXT1")
+	empty[2*4] = 0
+	_, err = ReadWith(bytes.NewReader(empty), &Options{Mode: Lenient})
+	var malformed *HeaderError
+	assert.ErrorAs(t, err, &malformed)
+
+	wrongMagic := validHeader("DXT1")
+	copy(wrongMagic, "DDT ")
+	_, err = ReadWith(bytes.NewReader(wrongMagic), &Options{Mode: Lenient})
+	assert.ErrorAs(t, err, &malformed)
+}
+
+func TestRead_Palette(t *testing.T) {
+	data := validHeader("")
+	data[20*4] = byte(DDPFPaletteIndexed8)
+	palette := make([]byte, 1024)
+	copy(palette[4:], []byte{1, 2, 3, 4})
+
+	h, err := Read(bytes.NewReader(append(data, palette...)))
+	assert.NoError(t, err)
+	assert.Len(t, h.Palette, 256)
+	assert.Equal(t, color.NRGBA{R: 1, G: 2, B: 3, A: 4}, h.Palette[1])
+	assert.EqualValues(t, 128+1024, h.Size())
+
+	_, err = Read(bytes.NewReader(append(data, palette[:100]...)))
+	var truncated *TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, TruncatedError{Section: "palette", Offset: 128, Expected: 1024, Received: 100}, *truncated)
+	}
+}
@@ -0,0 +1,271 @@
+package header
+
+import (
+	"fmt"
+)
+
+// Surface describes where a single surface of a texture is stored. The surfaces of a texture are stored one after
+// another: every element of a texture array holds all faces of a cube map, every face holds all mip maps, and
+// every mip map of a volume texture holds all its slices.
+type Surface struct {
+	Index  int   // element of a texture array
+	Face   int   // face of a cube map in the order +x, -x, +y, -y, +z, -z; zero for other textures
+	Mip    int   // mip map level, zero is the full size
+	Width  int   // width in pixels
+	Height int   // height in pixels
+	Depth  int   // number of slices of a volume texture, otherwise one
+	Pitch  int64 // bytes of a row of pixels, or of a row of blocks for compressed formats
+	Offset int64 // position of the surface relative to the end of the header
+	Size   int64 // bytes of the surface
+}
+
+func (s Surface) String() string {
+	return fmt.Sprintf("surface (element %d, face %d, mip %d)", s.Index, s.Face, s.Mip)
+}
+
+// layout describes how the pixels of a format are stored, in blocks of size×size pixels taking the given bits.
+type layout struct {
+	size, bits int
+}
+
+// legacy FourCC codes with a known layout
+var fourCCLayouts = map[string]layout{
+	"DXT1": {4, 64}, "DXT2": {4, 128}, "DXT3": {4, 128}, "DXT4": {4, 128}, "DXT5": {4, 128},
+	"ATI1": {4, 64}, "BC4U": {4, 64}, "BC4S": {4, 64},
+	"ATI2": {4, 128}, "BC5U": {4, 128}, "BC5S": {4, 128},
+
+	// numeric D3DFMT codes by their names
+	"A16B16G16R16": {1, 64}, "Q16W16V16U16": {1, 64}, "R16F": {1, 16}, "G16R16F": {1, 32},
+	"A16B16G16R16F": {1, 64}, "R32F": {1, 32}, "G32R32F": {1, 64}, "A32B32G32R32F": {1, 128}, "CxV8U8": {1, 16},
+}
+
+// DXGI formats with a known layout as ranges of consecutive values
+var dxgiLayouts = []struct {
+	first, last uint32
+	layout
+}{
+	{1, 4, layout{1, 128}},    // R32G32B32A32
+	{5, 8, layout{1, 96}},     // R32G32B32
+	{9, 14, layout{1, 64}},    // R16G16B16A16
+	{15, 22, layout{1, 64}},   // R32G32, R32G8X24
+	{23, 25, layout{1, 32}},   // R10G10B10A2
+	{26, 26, layout{1, 32}},   // R11G11B10_FLOAT
+	{27, 47, layout{1, 32}},   // R8G8B8A8, R16G16, R32, R24G8
+	{48, 59, layout{1, 16}},   // R8G8, R16
+	{60, 65, layout{1, 8}},    // R8, A8
+	{67, 67, layout{1, 32}},   // R9G9B9E5_SHAREDEXP
+	{70, 72, layout{4, 64}},   // BC1
+	{73, 78, layout{4, 128}},  // BC2, BC3
+	{79, 81, layout{4, 64}},   // BC4
+	{82, 84, layout{4, 128}},  // BC5
+	{85, 86, layout{1, 16}},   // B5G6R5, B5G5R5A1
+	{87, 93, layout{1, 32}},   // B8G8R8A8, B8G8R8X8, R10G10B10_XR_BIAS_A2
+	{94, 99, layout{4, 128}},  // BC6H, BC7
+	{115, 115, layout{1, 16}}, // B4G4R4A4
+}
+
+// layout returns the storage layout of the texture format.
+func (h *Header) layout() (layout, error) {
+	switch {
+	case h.PixelFlags.Has(DDPFFourCC) && h.FourCCString == FourCCDX10:
+		for _, l := range dxgiLayouts {
+			if l.first <= h.DxgiFormat && h.DxgiFormat <= l.last {
+				return l.layout, nil
+			}
+		}
+	case h.PixelFlags.Has(DDPFFourCC):
+		if l, ok := fourCCLayouts[h.FourCCString]; ok {
+			return l, nil
+		}
+	case h.RgbBitCount != 0:
+		return layout{1, int(h.RgbBitCount)}, nil
+	}
+	return layout{}, NewFormatError(h, "unknown storage size")
+}
+
+// Format describes how the pixels of a texture are stored, which is all that is needed to use its surfaces without
+// decoding them, e.g. to upload them to the GPU.
+type Format struct {
+	FourCC      string    // FourCC of the texture if it has one, see Header.FourCCString
+	DxgiFormat  uint32    // the DXGI format of textures with a DX10 header
+	PixelFlags  DDPFf     // the pixel format flags
+	RgbBitCount uint32    // bits per pixel of uncompressed textures without a FourCC
+	Masks       [4]uint32 // red, green, blue and alpha masks of uncompressed textures without a FourCC
+	BlockSize   int       // width and height of a block in pixels, 4 for compressed formats and 1 otherwise
+	BlockBits   int       // bits per block, or per pixel if BlockSize is 1
+}
+
+// Compressed returns if the format stores blocks of pixels.
+func (f Format) Compressed() bool {
+	return f.BlockSize > 1
+}
+
+// Format returns the storage format of the texture. Textures in a format with an unknown storage size produce a
+// *FormatError.
+func (h *Header) Format() (Format, error) {
+	l, err := h.layout()
+	if err != nil {
+		return Format{}, err
+	}
+	f := Format{PixelFlags: h.PixelFlags.F, BlockSize: l.size, BlockBits: l.bits}
+	if h.PixelFlags.Has(DDPFFourCC) {
+		f.FourCC = h.FourCCString
+		if f.FourCC == FourCCDX10 {
+			f.DxgiFormat = h.DxgiFormat
+		}
+	} else {
+		f.RgbBitCount = h.RgbBitCount
+		f.Masks = [4]uint32{h.RBitMask, h.GBitMask, h.BBitMask, h.ABitMask}
+	}
+	return f, nil
+}
+
+// pitch returns the bytes of a row of pixels or blocks of a surface with the given width.
+func (l layout) pitch(width int) int64 {
+	if l.size == 1 {
+		return (int64(width)*int64(l.bits) + 7) / 8
+	}
+	return int64(max(1, (width+l.size-1)/l.size)) * int64(l.bits/8)
+}
+
+// rows returns the number of rows of pixels or blocks of a surface with the given height.
+func (l layout) rows(height int) int64 {
+	return int64(max(1, (height+l.size-1)/l.size))
+}
+
+// Pitch returns the bytes of a row of pixels, or of a row of blocks for compressed formats, of the largest mip map.
+// For uncompressed formats a declared pitch (DDSDPitch) is honored if it pads the rows, otherwise the pitch is
+// computed from the width. Textures in a format with an unknown storage size produce a *FormatError.
+func (h *Header) Pitch() (int64, error) {
+	l, err := h.layout()
+	if err != nil {
+		return 0, err
+	}
+	return l.padded(h), nil
+}
+
+// padded returns the pitch of the largest mip map of h, see Header.Pitch.
+func (l layout) padded(h *Header) int64 {
+	pitch := l.pitch(int(h.Width))
+	if l.size == 1 && h.TextureFlags.Has(DDSDPitch) && int64(h.PitchOrLinearSize) > pitch {
+		pitch = int64(h.PitchOrLinearSize)
+	}
+	return pitch
+}
+
+// Surfaces returns all surfaces of the texture in the order they are stored. Textures in a format with an unknown
+// storage size produce a *FormatError, cube maps without faces a *HeaderError, so that the list is never empty. As
+// the number of surfaces is taken from the header, untrusted headers should be checked against Limits first.
+func (h *Header) Surfaces() ([]Surface, error) {
+	l, err := h.layout()
+	if err != nil {
+		return nil, err
+	}
+	c, err := h.counts()
+	if err != nil {
+		return nil, err
+	}
+
+	surfaces := make([]Surface, 0, c.elements*len(c.faces)*c.mips)
+	var offset int64
+	for e := 0; e < c.elements; e++ {
+		for _, f := range c.faces {
+			for m := 0; m < c.mips; m++ {
+				s := l.surface(h, e, f, m, c.depth, offset)
+				offset += s.Size
+				surfaces = append(surfaces, s)
+			}
+		}
+	}
+	return surfaces, nil
+}
+
+// FirstSurface returns the first surface stored, the largest mip map of the first face and array element, without
+// building the list of all surfaces. It fails like Surfaces.
+func (h *Header) FirstSurface() (Surface, error) {
+	l, err := h.layout()
+	if err != nil {
+		return Surface{}, err
+	}
+	c, err := h.counts()
+	if err != nil {
+		return Surface{}, err
+	}
+	return l.surface(h, 0, c.faces[0], 0, c.depth, 0), nil
+}
+
+// counts holds the number of mip maps, the stored cube map faces, the array elements and the depth of a texture.
+type counts struct {
+	mips     int
+	faces    []int
+	elements int
+	depth    int
+}
+
+// counts returns how many surfaces the texture stores, or a *HeaderError for a cube map without faces.
+func (h *Header) counts() (counts, error) {
+	c := counts{mips: 1, faces: []int{0}, elements: 1, depth: 1}
+	if h.TextureFlags.Has(DDSDMipMapCount) && h.MipMapCount > 1 {
+		c.mips = int(h.MipMapCount)
+	}
+	if h.TextureFlags.Has(DDSDDepth) && h.Depth > 1 {
+		c.depth = int(h.Depth)
+	}
+	if h.FourCCString == FourCCDX10 {
+		c.elements = int(max(h.ArraySize, 1))
+		if h.MiscFlag&DDSResourceMiscTextureCube != 0 {
+			c.faces = []int{0, 1, 2, 3, 4, 5}
+		}
+	} else if h.Caps2&DDSCAPS2Cubemap != 0 {
+		// partial cube maps only store the faces that are present
+		c.faces = c.faces[:0]
+		for f := 0; f < 6; f++ {
+			if h.Caps2&(DDSCAPS2CubemapPositiveX<<f) != 0 {
+				c.faces = append(c.faces, f)
+			}
+		}
+		if len(c.faces) == 0 {
+			return counts{}, &HeaderError{Field: "Caps2", Value: h.Caps2, Reason: "cube map without faces"}
+		}
+	}
+	return c, nil
+}
+
+// surface returns the mip map m of the face f of the array element e of h, which is stored at offset.
+func (l layout) surface(h *Header, e, f, m, depth int, offset int64) Surface {
+	s := Surface{
+		Index:  e,
+		Face:   f,
+		Mip:    m,
+		Width:  max(1, int(h.Width)>>m),
+		Height: max(1, int(h.Height)>>m),
+		Depth:  max(1, depth>>m),
+		Offset: offset,
+	}
+	if s.Pitch = l.pitch(s.Width); m == 0 {
+		s.Pitch = l.padded(h) // the declared pitch only describes the largest mip map
+	}
+	s.Size = s.Pitch * l.rows(s.Height) * int64(s.Depth)
+	return s
+}
+
+// SurfaceHeader returns a copy of h describing only the surface s as a texture of its own, without mip maps, faces,
+// slices or array elements, so that the data of s can be decoded like a whole texture.
+func (h *Header) SurfaceHeader(s Surface) *Header {
+	c := *h
+	c.Width, c.Height = uint32(s.Width), uint32(s.Height)
+	c.MipMapCount, c.Depth, c.Caps2, c.Warnings = 0, 0, 0, nil
+	c.TextureFlags.F &^= DDSDMipMapCount | DDSDDepth | DDSDPitch | DDSDLinearSize
+	if c.FourCCString == FourCCDX10 {
+		c.ArraySize, c.MiscFlag = 1, c.MiscFlag&^DDSResourceMiscTextureCube
+	}
+
+	c.PitchOrLinearSize = uint32(s.Pitch)
+	if l, err := h.layout(); err == nil && l.size > 1 {
+		c.TextureFlags.F |= DDSDLinearSize
+		c.PitchOrLinearSize = uint32(s.Size / int64(s.Depth))
+	} else {
+		c.TextureFlags.F |= DDSDPitch
+	}
+	return &c
+}
@@ -0,0 +1,164 @@
+package header
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeader_Surfaces(t *testing.T) {
+	texture := func(fourCC string, width, height uint32) *Header {
+		return &Header{
+			DDSHeader:    DDSHeader{TextureFlags: Flags[DDSf]{DDSDHeaderFlagsTexture}, Width: width, Height: height},
+			DDPFHeader:   DDPFHeader{PixelFlags: Flags[DDPFf]{DDPFFourCC}},
+			FourCCString: fourCC,
+		}
+	}
+
+	t.Run("mip maps", func(t *testing.T) {
+		h := texture("DXT1", 10, 4)
+		h.TextureFlags.F |= DDSDMipMapCount
+		h.MipMapCount = 4
+
+		surfaces, err := h.Surfaces()
+		assert.NoError(t, err)
+		assert.Equal(t, []Surface{
+			{Width: 10, Height: 4, Depth: 1, Pitch: 24, Offset: 0, Size: 24},
+			{Mip: 1, Width: 5, Height: 2, Depth: 1, Pitch: 16, Offset: 24, Size: 16},
+			{Mip: 2, Width: 2, Height: 1, Depth: 1, Pitch: 8, Offset: 40, Size: 8},
+			{Mip: 3, Width: 1, Height: 1, Depth: 1, Pitch: 8, Offset: 48, Size: 8},
+		}, surfaces)
+	})
+
+	t.Run("cube map", func(t *testing.T) {
+		h := texture("", 3, 2)
+		h.PixelFlags.F, h.RgbBitCount = DDPFRGB, 24
+		h.Caps2 = DDSCAPS2Cubemap | DDSCAPS2CubemapPositiveX | DDSCAPS2CubemapNegativeZ
+
+		surfaces, err := h.Surfaces()
+		assert.NoError(t, err)
+		assert.Equal(t, []Surface{
+			{Face: 0, Width: 3, Height: 2, Depth: 1, Pitch: 9, Offset: 0, Size: 18},
+			{Face: 5, Width: 3, Height: 2, Depth: 1, Pitch: 9, Offset: 18, Size: 18},
+		}, surfaces)
+	})
+
+	t.Run("array of volumes", func(t *testing.T) {
+		h := texture(FourCCDX10, 4, 4)
+		h.TextureFlags.F |= DDSDDepth | DDSDMipMapCount
+		h.Depth, h.MipMapCount = 4, 2
+		h.DxgiFormat, h.ArraySize = 28, 2 // R8G8B8A8_UNORM
+
+		surfaces, err := h.Surfaces()
+		assert.NoError(t, err)
+		assert.Len(t, surfaces, 4)
+		assert.Equal(t, Surface{Index: 1, Mip: 1, Width: 2, Height: 2, Depth: 2, Pitch: 8, Offset: 256 + 32 + 256, Size: 32},
+			surfaces[3])
+	})
+
+	t.Run("cube map without faces", func(t *testing.T) {
+		h := texture("DXT1", 4, 4)
+		h.Caps2 = DDSCAPS2Cubemap
+
+		_, err := h.Surfaces()
+		var headerErr *HeaderError
+		if assert.ErrorAs(t, err, &headerErr) {
+			assert.Equal(t, "Caps2", headerErr.Field)
+		}
+		assert.ErrorIs(t, err, ErrMalformed)
+		_, err = h.FirstSurface()
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := texture("UYVY", 4, 4).Surfaces()
+		assert.ErrorIs(t, err, ErrUnsupported)
+		_, err = texture("UYVY", 4, 4).FirstSurface()
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+}
+
+func TestHeader_FirstSurface(t *testing.T) {
+	h := &Header{
+		DDSHeader:    DDSHeader{TextureFlags: Flags[DDSf]{DDSDHeaderFlagsTexture | DDSDMipMapCount}, Width: 8, Height: 4},
+		DDPFHeader:   DDPFHeader{PixelFlags: Flags[DDPFf]{DDPFFourCC}},
+		FourCCString: "DXT5",
+	}
+	h.MipMapCount, h.Caps2 = 3, DDSCAPS2Cubemap|DDSCAPS2CubemapNegativeY|DDSCAPS2CubemapPositiveZ
+
+	surfaces, err := h.Surfaces()
+	assert.NoError(t, err)
+	first, err := h.FirstSurface()
+	assert.NoError(t, err)
+	assert.Equal(t, surfaces[0], first)
+	assert.Equal(t, 3, first.Face)
+}
+
+func TestHeader_Format(t *testing.T) {
+	h := &Header{DDPFHeader: DDPFHeader{PixelFlags: Flags[DDPFf]{DDPFFourCC}}, FourCCString: FourCCDX10}
+	h.DxgiFormat = 71 // BC1_UNORM
+	f, err := h.Format()
+	assert.NoError(t, err)
+	assert.Equal(t, Format{FourCC: FourCCDX10, DxgiFormat: 71, PixelFlags: DDPFFourCC, BlockSize: 4, BlockBits: 64}, f)
+	assert.True(t, f.Compressed())
+
+	h = &Header{DDPFHeader: DDPFHeader{
+		PixelFlags: Flags[DDPFf]{DDPFRGB | DDPFAlphaPixels}, RgbBitCount: 32,
+		RBitMask: 0xff0000, GBitMask: 0xff00, BBitMask: 0xff, ABitMask: 0xff000000,
+	}}
+	f, err = h.Format()
+	assert.NoError(t, err)
+	assert.Equal(t, Format{
+		PixelFlags: DDPFRGB | DDPFAlphaPixels, RgbBitCount: 32,
+		Masks: [4]uint32{0xff0000, 0xff00, 0xff, 0xff000000}, BlockSize: 1, BlockBits: 32,
+	}, f)
+	assert.False(t, f.Compressed())
+
+	h = &Header{DDPFHeader: DDPFHeader{PixelFlags: Flags[DDPFf]{DDPFFourCC}}, FourCCString: "UYVY"}
+	_, err = h.Format()
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestHeader_SurfaceHeader(t *testing.T) {
+	h := &Header{
+		DDSHeader: DDSHeader{
+			TextureFlags: Flags[DDSf]{DDSDHeaderFlagsTexture | DDSDPitch | DDSDMipMapCount},
+			Width:        6, Height: 4, PitchOrLinearSize: 20, MipMapCount: 3,
+		},
+		DDPFHeader: DDPFHeader{PixelFlags: Flags[DDPFf]{DDPFRGB}, RgbBitCount: 24},
+		CapsHeader: CapsHeader{Caps2: DDSCAPS2Cubemap | DDSCAPS2CubemapPositiveY},
+	}
+	surfaces, err := h.Surfaces()
+	assert.NoError(t, err)
+
+	mip := h.SurfaceHeader(surfaces[1])
+	assert.Equal(t, uint32(3), mip.Width)
+	assert.Equal(t, uint32(2), mip.Height)
+	assert.Equal(t, uint32(0), mip.Caps2)
+	assert.False(t, mip.TextureFlags.Has(DDSDMipMapCount))
+	only, err := mip.Surfaces()
+	assert.NoError(t, err)
+	assert.Equal(t, []Surface{{Width: 3, Height: 2, Depth: 1, Pitch: 9, Size: 18}}, only)
+
+	padded := h.SurfaceHeader(surfaces[0])
+	pitch, err := padded.Pitch()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), pitch, "the declared pitch of the largest mip map is kept")
+
+	h = &Header{
+		DDSHeader:    DDSHeader{TextureFlags: Flags[DDSf]{DDSDHeaderFlagsTexture}, Width: 8, Height: 8},
+		DDPFHeader:   DDPFHeader{PixelFlags: Flags[DDPFf]{DDPFFourCC}},
+		DX10Header:   DX10Header{DxgiFormat: 71, ArraySize: 3, MiscFlag: DDSResourceMiscTextureCube},
+		FourCCString: FourCCDX10,
+	}
+	surfaces, err = h.Surfaces()
+	assert.NoError(t, err)
+	assert.Len(t, surfaces, 18)
+	face := h.SurfaceHeader(surfaces[17])
+	assert.Equal(t, uint32(1), face.ArraySize)
+	assert.Equal(t, uint32(32), face.PitchOrLinearSize)
+	assert.True(t, face.TextureFlags.Has(DDSDLinearSize))
+	only, err = face.Surfaces()
+	assert.NoError(t, err)
+	assert.Len(t, only, 1)
+}
@@ -2,13 +2,13 @@
 package dds
 
 import (
-	"errors"
-	"fmt"
+	"context"
 	"image"
 	"image/color"
 	"io"
 
-	"github.com/funatsufumiya/dds-simd/decoder"
+	"github.com/funatsufumiya/dds-simd/decoder/uncompressed"
+	"github.com/funatsufumiya/dds-simd/hdr"
 	"github.com/funatsufumiya/dds-simd/header"
 )
 
@@ -17,33 +17,42 @@ func init() {
 	image.RegisterFormat("dds", "DDS ", Decode, DecodeConfig)
 }
 
-var ErrUnsupported = errors.New("unsupported texture format")
+// ErrUnsupported is wrapped by all errors about textures in a format that cannot be decoded, which are of the
+// type *header.FormatError.
+var ErrUnsupported = header.ErrUnsupported
 
+// DecodeConfig reads the header of a dds file from r and returns the dimensions and the color model of the texture.
+// Textures in a format that cannot be decoded produce an error wrapping ErrUnsupported.
 func DecodeConfig(r io.Reader) (image.Config, error) {
-	h, err := header.Read(r)
-	if err != nil {
-		return image.Config{}, err
-	}
+	return new(Decoder).DecodeConfig(r)
+}
 
+// config returns the dimensions and the color model of the texture described by h, decoded with the tone map.
+func config(h *header.Header, toneMap hdr.ToneMap) (c image.Config, err error) {
 	// set width and height
-	c := image.Config{
+	c = image.Config{
 		Width:  int(h.Width),
 		Height: int(h.Height),
 	}
 
+	if m := uncompressed.ColorModel(h, toneMap); m != nil {
+		c.ColorModel = m
+		return c, nil
+	}
+
 	switch pf, s := h.PixelFlags, h.RgbBitCount; {
 	case pf.Is(header.DDPFFourCC):
 		switch h.FourCCString {
 		case header.FourCCDX10:
-			err = ErrUnsupported
+			err = header.NewFormatError(h, "")
 		case "DXT1", "DXT3", "DXT5":
 			c.ColorModel = color.NRGBAModel
 		default:
-			err = fmt.Errorf("%w; is %s", ErrUnsupported, h.FourCCString)
+			err = header.NewFormatError(h, "")
 		}
 
 	case pf.Has(header.DDPFRGB): // because alpha is implicit
-		err = ErrUnsupported
+		err = header.NewFormatError(h, "")
 
 		if s <= 32 {
 			c.ColorModel = color.NRGBAModel
@@ -51,11 +60,11 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 			c.ColorModel = color.NRGBA64Model
 		}
 	case pf.Is(header.DDPFYUV):
-		err = ErrUnsupported
+		err = header.NewFormatError(h, "")
 
 		c.ColorModel = color.NYCbCrAModel
 	case pf.Is(header.DDPFLuminance):
-		err = ErrUnsupported
+		err = header.NewFormatError(h, "")
 
 		if s <= 8 {
 			c.ColorModel = color.GrayModel
@@ -63,7 +72,7 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 			c.ColorModel = color.Gray16Model
 		}
 	case pf.Is(header.DDPFAlpha):
-		err = ErrUnsupported
+		err = header.NewFormatError(h, "")
 
 		if s <= 8 {
 			c.ColorModel = color.AlphaModel
@@ -71,7 +80,7 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 			c.ColorModel = color.Alpha16Model
 		}
 	case pf.Is(header.DDPFLuminance | header.DDPFAlphaPixels):
-		err = ErrUnsupported
+		err = header.NewFormatError(h, "")
 
 		if s <= 32 {
 			c.ColorModel = color.NRGBAModel // R__A
@@ -79,22 +88,26 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 			c.ColorModel = color.NRGBA64Model // R__A
 		}
 	default:
-		err = fmt.Errorf("unrecognized image format: pf.flags: %x", pf)
+		err = header.NewFormatError(h, "unrecognized pixel flags")
 	}
 
 	return c, err
 }
 
+// Decode reads a dds file from r with the default settings of a Decoder.
 func Decode(r io.Reader) (image.Image, error) {
-	h, err := header.Read(r)
-	if err != nil {
-		return nil, err
-	}
+	return new(Decoder).Decode(r)
+}
 
-	d, err := decoder.Find(h)
-	if err != nil {
-		return nil, err
-	}
+// DecodeContext reads a dds file from r like Decode, but stops with ctx.Err() once ctx is done. The context is checked
+// between rows of blocks or pixels.
+func DecodeContext(ctx context.Context, r io.Reader) (image.Image, error) {
+	return new(Decoder).DecodeContext(ctx, r)
+}
 
-	return d.Decode(r)
+// DecodeRegion reads a dds file from r and decodes only the part of the texture within rect, which is clipped to
+// the bounds of the texture. The returned image keeps the coordinates of the texture. The data before the region is
+// skipped, by seeking if r is an io.Seeker like an *os.File, and the data after it is not read.
+func DecodeRegion(r io.Reader, rect image.Rectangle) (image.Image, error) {
+	return new(Decoder).DecodeRegion(r, rect)
 }
@@ -0,0 +1,91 @@
+package dds
+
+import (
+	"io"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// Progress describes how far a Decoder has come with the surfaces of a texture, see Decoder.Progress. The package
+// has no encoder, so there is no progress of writing textures.
+type Progress struct {
+	Surface  int   // index of the surface being read
+	Surfaces int   // number of surfaces to read
+	Done     int64 // blocks of the surface read so far, pixels for uncompressed formats
+	Total    int64 // blocks of the surface, pixels for uncompressed formats
+}
+
+// progressReader reports the progress of reading the given surfaces from r, whose data starts at the offset of the
+// first surface.
+type progressReader struct {
+	r        io.Reader
+	report   func(Progress)
+	surfaces []header.Surface
+	blocks   []int64 // blocks per surface
+	pos      int64   // position relative to the first surface
+	current  int     // index of the surface at the position, which only moves forward
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.advance(int64(n))
+	}
+	return n, err
+}
+
+// advance moves the position by n bytes and reports the progress of the surface it is in.
+func (r *progressReader) advance(n int64) {
+	r.pos += n
+	if len(r.surfaces) == 0 {
+		return
+	}
+	pos := r.surfaces[0].Offset + r.pos
+	i := r.current
+	for i < len(r.surfaces)-1 && r.surfaces[i].Offset+r.surfaces[i].Size < pos {
+		i++
+	}
+	r.current = i
+	s := r.surfaces[i]
+	done := r.blocks[i]
+	if s.Size > 0 {
+		done = min(done, max(0, pos-s.Offset)*r.blocks[i]/s.Size)
+	}
+	r.report(Progress{Surface: i, Surfaces: len(r.surfaces), Done: done, Total: r.blocks[i]})
+}
+
+// progressReadSeeker is a progressReader that keeps the ability to seek, which is used to skip data.
+type progressReadSeeker struct {
+	progressReader
+	s io.Seeker
+}
+
+func (r *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	n, err := r.s.Seek(offset, whence)
+	if err == nil && whence == io.SeekCurrent {
+		r.advance(offset)
+	}
+	return n, err
+}
+
+// withProgress returns a reader that reports the progress of reading the surfaces of the texture h from r to the
+// Progress function of the decoder. Without a function, r itself is returned.
+func (d *Decoder) withProgress(r io.Reader, h *header.Header, surfaces []header.Surface) io.Reader {
+	if d.Progress == nil || len(surfaces) == 0 {
+		return r
+	}
+	f, err := h.Format()
+	if err != nil {
+		return r // the decoders report unsupported formats
+	}
+
+	p := progressReader{r: r, report: d.Progress, surfaces: surfaces, blocks: make([]int64, len(surfaces))}
+	for i, s := range surfaces {
+		size := f.BlockSize
+		p.blocks[i] = int64((s.Width+size-1)/size) * int64((s.Height+size-1)/size) * int64(s.Depth)
+	}
+	if s, ok := r.(io.Seeker); ok {
+		return &progressReadSeeker{progressReader: p, s: s}
+	}
+	return &p
+}
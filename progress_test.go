@@ -0,0 +1,56 @@
+package dds
+
+import (
+	"bytes"
+	"image"
+	"testing"
+	"testing/iotest"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_Progress(t *testing.T) {
+	var reports []Progress
+	d := Decoder{Progress: func(p Progress) { reports = append(reports, p) }}
+
+	// every row of blocks is read at once
+	_, err := d.Decode(bytes.NewReader(newTexture("DXT1", 16, 16, make([]byte, 128))))
+	assert.NoError(t, err)
+	assert.Equal(t, []Progress{
+		{Surfaces: 1, Done: 4, Total: 16}, {Surfaces: 1, Done: 8, Total: 16},
+		{Surfaces: 1, Done: 12, Total: 16}, {Surfaces: 1, Done: 16, Total: 16},
+	}, reports)
+
+	// skipped blocks count as done
+	reports = nil
+	_, err = d.DecodeRegion(bytes.NewReader(newTexture("DXT1", 16, 16, make([]byte, 128))), image.Rect(12, 12, 16, 16))
+	assert.NoError(t, err)
+	assert.Equal(t, []Progress{{Surfaces: 1, Done: 15, Total: 16}, {Surfaces: 1, Done: 16, Total: 16}}, reports)
+
+	// the surfaces of a texture with mip maps
+	file := newTexture("DXT1", 8, 8, make([]byte, 40))
+	file[8+2] |= 0x2 // DDSDMipMapCount
+	file[7*4] = 2    // mip map count
+	reports = nil
+	_, err = d.ReadTexture(iotest.OneByteReader(bytes.NewReader(file)))
+	assert.NoError(t, err)
+	assert.Contains(t, reports, Progress{Surface: 0, Surfaces: 2, Done: 4, Total: 4})
+	assert.Equal(t, Progress{Surface: 1, Surfaces: 2, Done: 1, Total: 1}, reports[len(reports)-1])
+
+	d.Progress = nil
+	_, err = d.Decode(bytes.NewReader(newTexture("DXT1", 16, 16, make([]byte, 128))))
+	assert.NoError(t, err)
+}
+
+func TestDecoder_ProgressCubeMapWithoutFaces(t *testing.T) {
+	d := Decoder{Progress: func(Progress) {}}
+	file := newTexture("DXT1", 4, 4, make([]byte, 8))
+	file[28*4+1] = 0x2 // DDSCAPS2_CUBEMAP without any face
+
+	assert.NotPanics(t, func() {
+		_, err := d.Decode(bytes.NewReader(file))
+		assert.ErrorIs(t, err, header.ErrMalformed)
+		_, _ = d.DecodeRegion(bytes.NewReader(file), image.Rect(0, 0, 4, 4))
+	})
+}
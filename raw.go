@@ -0,0 +1,71 @@
+package dds
+
+import (
+	"context"
+	"io"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// Texture holds the undecoded contents of a dds file, e.g. to upload block compressed surfaces to the GPU as they
+// are stored.
+type Texture struct {
+	Header   *header.Header
+	Format   header.Format // how the pixels of all surfaces are stored
+	Surfaces []Surface     // the surfaces in the order they are stored, see header.Header.Surfaces
+}
+
+// Surface is a single surface of a Texture. Its Offset is relative to the end of the header, which is
+// Header.Size() bytes long.
+type Surface struct {
+	header.Surface
+	Data []byte // the bytes of the surface as stored in the file, including the padding of its rows
+}
+
+// ReadTexture reads a dds file from r without decoding it and returns the data of every surface. All surfaces need
+// to be complete, a missing one produces a *header.TruncatedError. Data following the last surface is not read.
+// Textures in a format with an unknown storage size produce an error wrapping ErrUnsupported.
+func ReadTexture(r io.Reader) (*Texture, error) {
+	return new(Decoder).ReadTexture(r)
+}
+
+// ReadTexture reads a dds file like the package level ReadTexture, using the header mode and limits of the decoder.
+func (d *Decoder) ReadTexture(r io.Reader) (*Texture, error) {
+	return d.ReadTextureContext(context.Background(), r)
+}
+
+// ReadTextureContext reads a dds file like ReadTexture, but stops with ctx.Err() once ctx is done. The context is
+// checked before every read of the surfaces.
+func (d *Decoder) ReadTextureContext(ctx context.Context, r io.Reader) (*Texture, error) {
+	h, err := d.header(r)
+	if err != nil {
+		return nil, err
+	}
+	format, err := h.Format()
+	if err != nil {
+		return nil, err
+	}
+	surfaces, err := h.Surfaces()
+	if err != nil {
+		return nil, err
+	} else if len(surfaces) == 0 {
+		return nil, &header.HeaderError{Field: "Caps2", Value: h.Caps2, Reason: "texture without surfaces"}
+	}
+
+	// the buffer only grows with the data actually read, so a header declaring a huge texture in a short file
+	// does not allocate more than the file holds
+	data, err := io.ReadAll(io.LimitReader(withContext(ctx, d.withProgress(r, h, surfaces)), dataSize(surfaces)))
+	if err != nil {
+		return nil, err
+	}
+	if err = incomplete(h, surfaces, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	t := &Texture{Header: h, Format: format, Surfaces: make([]Surface, len(surfaces))}
+	for i, s := range surfaces {
+		end := s.Offset + s.Size
+		t.Surfaces[i] = Surface{Surface: s, Data: data[s.Offset:end:end]}
+	}
+	return t, nil
+}
@@ -0,0 +1,54 @@
+package dds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTexture(t *testing.T) {
+	payload := make([]byte, 40)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	file := newTexture("DXT1", 8, 8, payload)
+	file[8+2] |= 0x2 // DDSDMipMapCount
+	file[7*4] = 2    // mip map count
+
+	texture, err := ReadTexture(bytes.NewReader(append(file, 1, 2, 3)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, header.Format{FourCC: "DXT1", PixelFlags: header.DDPFFourCC, BlockSize: 4, BlockBits: 64},
+			texture.Format)
+		assert.Equal(t, []Surface{
+			{
+				Surface: header.Surface{Width: 8, Height: 8, Depth: 1, Pitch: 16, Size: 32},
+				Data:    payload[:32],
+			},
+			{
+				Surface: header.Surface{Mip: 1, Width: 4, Height: 4, Depth: 1, Pitch: 8, Offset: 32, Size: 8},
+				Data:    payload[32:],
+			},
+		}, texture.Surfaces)
+	}
+
+	_, err = ReadTexture(bytes.NewReader(file[:len(file)-1]))
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, header.TruncatedError{
+			Section: "surface (element 0, face 0, mip 1)", Offset: 160, Expected: 8, Received: 7,
+		}, *truncated)
+	}
+
+	_, err = ReadTexture(bytes.NewReader(newTexture("UYVY", 4, 4, nil)))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestReadTexture_CubeMapWithoutFaces(t *testing.T) {
+	file := newTexture("DXT1", 4, 4, make([]byte, 8))
+	file[28*4+1] = 0x2 // DDSCAPS2_CUBEMAP without any face
+
+	_, err := ReadTexture(bytes.NewReader(file))
+	assert.ErrorIs(t, err, header.ErrMalformed)
+}
@@ -0,0 +1,63 @@
+package dds
+
+import (
+	"io"
+
+	"github.com/funatsufumiya/dds-simd/header"
+)
+
+// Validate reads a dds file from r completely and checks that the data matches the surfaces announced by the
+// header, see header.Header.Surfaces. Missing data produces a *header.TruncatedError naming the first incomplete
+// surface, data following the last surface a *header.TrailingDataError.
+func Validate(r io.Reader) error {
+	return new(Decoder).Validate(r)
+}
+
+// Validate checks a dds file like the package level Validate, using the header mode and limits of the decoder.
+func (d *Decoder) Validate(r io.Reader) error {
+	h, err := d.header(r)
+	if err != nil {
+		return err
+	}
+	surfaces, err := h.Surfaces()
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return err
+	}
+	if err = incomplete(h, surfaces, n); err != nil {
+		return err
+	}
+	if end := dataSize(surfaces); n > end {
+		return &header.TrailingDataError{Offset: h.Size() + end, Size: n - end}
+	}
+	return nil
+}
+
+// dataSize returns the bytes of texture data holding the surfaces, which are stored one after another.
+func dataSize(surfaces []header.Surface) int64 {
+	if len(surfaces) == 0 {
+		return 0
+	}
+	last := surfaces[len(surfaces)-1]
+	return last.Offset + last.Size
+}
+
+// incomplete returns a *header.TruncatedError for the first of the surfaces of h that is not covered by n bytes of
+// texture data, or nil if all of them are complete.
+func incomplete(h *header.Header, surfaces []header.Surface, n int64) error {
+	for _, s := range surfaces {
+		if s.Offset+s.Size > n {
+			return &header.TruncatedError{
+				Section:  s.String(),
+				Offset:   h.Size() + s.Offset,
+				Expected: s.Size,
+				Received: max(0, n-s.Offset),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package dds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/funatsufumiya/dds-simd/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	valid := newTexture("DXT1", 8, 8, make([]byte, 32))
+	assert.NoError(t, Validate(bytes.NewReader(valid)))
+
+	_, err := Decode(bytes.NewReader(append(valid, 1, 2, 3)))
+	assert.NoError(t, err, "decoding ignores trailing data")
+	err = Validate(bytes.NewReader(append(valid, 1, 2, 3)))
+	var trailing *header.TrailingDataError
+	if assert.ErrorAs(t, err, &trailing) {
+		assert.Equal(t, header.TrailingDataError{Offset: 160, Size: 3}, *trailing)
+	}
+
+	// the second mip map of 4x4 pixels is missing
+	mipMaps := newTexture("DXT1", 8, 8, make([]byte, 36))
+	mipMaps[8+2] |= 0x2                      // DDSDMipMapCount
+	mipMaps[7*4] = 2                         // mip map count
+	err = Validate(bytes.NewReader(mipMaps)) // the decoder only needs the first mip map
+	var truncated *header.TruncatedError
+	if assert.ErrorAs(t, err, &truncated) {
+		assert.Equal(t, header.TruncatedError{
+			Section: "surface (element 0, face 0, mip 1)", Offset: 160, Expected: 8, Received: 4,
+		}, *truncated)
+	}
+
+	_, err = Decode(bytes.NewReader(mipMaps))
+	assert.NoError(t, err)
+}
+
+func TestValidate_CubeMapWithoutFaces(t *testing.T) {
+	// a cube map of 136 bytes without any of the face bits must not crash the decoders
+	file := newTexture("DXT1", 4, 4, make([]byte, 8))
+	binary.LittleEndian.PutUint32(file[28*4:], 0x200) // DDSCAPS2_CUBEMAP
+
+	assert.ErrorIs(t, Validate(bytes.NewReader(file)), header.ErrMalformed)
+	_, err := Decode(bytes.NewReader(file))
+	assert.ErrorIs(t, err, header.ErrMalformed)
+	_, err = Decode(struct{ io.Reader }{bytes.NewReader(file)})
+	assert.ErrorIs(t, err, header.ErrMalformed)
+	_, err = Open(bytes.NewReader(file))
+	assert.ErrorIs(t, err, header.ErrMalformed)
+}